@@ -0,0 +1,121 @@
+package rx
+
+import (
+	"context"
+	"sync"
+)
+
+// OverflowPolicy decides what OnBackpressureBuffer does with a value once its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowError terminates the Observable with ErrBufferOverflow.
+	OverflowError OverflowPolicy = iota
+	// OverflowDropNewest drops the value.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest value of the buffer to make room for the value.
+	OverflowDropOldest
+)
+
+// OnBackpressureBuffer subscribes to the source Observable on its own goroutine, and buffers at most capacity values
+// while the consumer is slower than the source. The overflowPolicy decides what happens once the buffer is full.
+// The optional onDrop is called, from the goroutine of the source, with every dropped value and the number of values dropped so far.
+func OnBackpressureBuffer[T any](capacity int, overflowPolicy OverflowPolicy, onDrop ...func(v T, dropped uint64)) OperatorFunc[T, T] {
+	if capacity <= 0 {
+		panic(`OnBackpressureBuffer required a positive capacity`)
+	}
+	return onBackpressure(capacity, overflowPolicy, onDrop)
+}
+
+// OnBackpressureDrop subscribes to the source Observable on its own goroutine,
+// and drops the values emitted while the consumer is busy.
+// The onDrop function may be nil, otherwise it's called, from the goroutine of the source,
+// with every dropped value and the number of values dropped so far.
+func OnBackpressureDrop[T any](onDrop func(v T, dropped uint64)) OperatorFunc[T, T] {
+	return onBackpressure(0, OverflowDropNewest, []func(T, uint64){onDrop})
+}
+
+// OnBackpressureLatest subscribes to the source Observable on its own goroutine,
+// and only keeps the latest value emitted while the consumer is busy.
+// The optional onDrop is called, from the goroutine of the source, with every dropped value and the number of values dropped so far.
+func OnBackpressureLatest[T any](onDrop ...func(v T, dropped uint64)) OperatorFunc[T, T] {
+	return onBackpressure(1, OverflowDropOldest, onDrop)
+}
+
+func onBackpressure[T any](capacity int, overflowPolicy OverflowPolicy, onDrop []func(T, uint64)) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := make(chan state[T], capacity)
+			wg.Go(func() {
+				var dropped uint64
+				drop := func(v T) {
+					dropped++
+					if len(onDrop) > 0 && onDrop[0] != nil {
+						onDrop[0](v, dropped)
+					}
+				}
+				// The notifications of the termination are never dropped.
+				send := func(n state[T]) {
+					select {
+					case <-ctx.Done():
+					case ch <- n:
+					}
+				}
+
+				var failure error
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						failure = err
+						break
+					}
+
+					select {
+					case ch <- state[T]{v, nil, true}:
+						continue
+					default:
+					}
+					if overflowPolicy == OverflowError {
+						// The source is unsubscribed before the error is delivered.
+						failure = ErrBufferOverflow
+						break
+					} else if overflowPolicy == OverflowDropOldest {
+						// Only this goroutine sends, so there is room once the oldest value is removed.
+						select {
+						case o := <-ch:
+							drop(o.v)
+						default:
+						}
+						send(state[T]{v, nil, true})
+					} else {
+						drop(v)
+					}
+				}
+				send(state[T]{err: failure})
+			})
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					} else {
+						if !yield(o.v, nil) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+}
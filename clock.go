@@ -0,0 +1,95 @@
+package rx
+
+import (
+	"context"
+	"time"
+)
+
+// Clock provides the current time and the timers used by the time-based operators.
+// It allows the time to be virtualised, see TestScheduler.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer creates a ClockTimer that sends the current time on its channel after at least duration d.
+	NewTimer(d time.Duration) ClockTimer
+	// NewTicker creates a ClockTicker that sends the current time on its channel after each period d.
+	NewTicker(d time.Duration) ClockTicker
+}
+
+// ClockTimer is the Clock counterpart of time.Timer.
+type ClockTimer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+	// Stop prevents the ClockTimer from firing, it reports whether the ClockTimer was active.
+	Stop() bool
+	// Reset changes the ClockTimer to expire after duration d, it reports whether the ClockTimer was active.
+	Reset(d time.Duration) bool
+}
+
+// ClockTicker is the Clock counterpart of time.Ticker.
+type ClockTicker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns off the ClockTicker.
+	Stop()
+}
+
+type realClock struct{}
+
+// RealClock returns the Clock backed by the time package, it's the default Clock of every operator.
+func RealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) ClockTimer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) ClockTicker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+type clockKey struct{}
+
+// WithClock returns a copy of ctx carrying the Clock.
+// Every time-based operator of a pipeline subscribed with this context uses the Clock,
+// unless a Clock is given to the operator itself.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, clock)
+}
+
+// ClockFromContext returns the Clock carried by ctx, or RealClock if there is none.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok && clock != nil {
+		return clock
+	}
+	return realClock{}
+}
+
+// clockOf returns the Clock given to an operator, falling back on the Clock of the pipeline.
+func clockOf(ctx context.Context, clock []Clock) Clock {
+	if len(clock) > 0 && clock[0] != nil {
+		return clock[0]
+	}
+	return ClockFromContext(ctx)
+}
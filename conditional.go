@@ -1,11 +1,13 @@
 package rx
 
+import "context"
+
 // DefaultIfEmpty emits a default value if the source Observable completes without emitting any value.
 func DefaultIfEmpty[T any](defaultValue T) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var emitted bool
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -28,10 +30,10 @@ func DefaultIfEmpty[T any](defaultValue T) OperatorFunc[T, T] {
 // Every returns an Observable that emits true or false to the Observer.
 func Every[T any](predicate func(T, int) bool) OperatorFunc[T, bool] {
 	return func(input Observable[T]) Observable[bool] {
-		return (ObservableFunc[bool])(func(yield func(bool, error) bool) {
+		return (ObservableContextFunc[bool])(func(ctx context.Context, yield func(bool, error) bool) {
 			var i int
 			var passed = true
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(false, err)
 					return
@@ -47,9 +49,9 @@ func Every[T any](predicate func(T, int) bool) OperatorFunc[T, bool] {
 // Find emits only the first value emitted by the source Observable that meets some condition.
 func Find[T any](predicate func(T, int) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -70,9 +72,9 @@ func Find[T any](predicate func(T, int) bool) OperatorFunc[T, T] {
 // FindIndex emits only the index of the first value emitted by the source Observable that meets some condition.
 func FindIndex[T any](predicate func(T, int) bool) OperatorFunc[T, int] {
 	return func(input Observable[T]) Observable[int] {
-		return (ObservableFunc[int])(func(yield func(int, error) bool) {
+		return (ObservableContextFunc[int])(func(ctx context.Context, yield func(int, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(-1, err)
 					return
@@ -92,8 +94,8 @@ func FindIndex[T any](predicate func(T, int) bool) OperatorFunc[T, int] {
 // IsEmpty returns an Observable that emits true if the source Observable is empty, otherwise false.
 func IsEmpty[T any]() OperatorFunc[T, bool] {
 	return func(input Observable[T]) Observable[bool] {
-		return (ObservableFunc[bool])(func(yield func(bool, error) bool) {
-			for _, err := range input.Subscribe() {
+		return (ObservableContextFunc[bool])(func(ctx context.Context, yield func(bool, error) bool) {
+			for _, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(false, err)
 					return
@@ -1,14 +1,16 @@
 package rx
 
 import (
+	"context"
+	"io"
 	"iter"
 	"time"
 )
 
 // Defer creates an Observable that, on subscription, calls an Observable factory to make an Observable for each new Observer.
 func Defer[T any](observableFactory func() Observable[T]) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
-		for v, err := range observableFactory().Subscribe() {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		for v, err := range observableFactory().SubscribeContext(ctx) {
 			if err != nil {
 				var zero T
 				yield(zero, err)
@@ -21,22 +23,65 @@ func Defer[T any](observableFactory func() Observable[T]) Observable[T] {
 	})
 }
 
+// Using creates an Observable that, on subscription, acquires a resource with resourceFactory and subscribes to the Observable
+// returned by observableFactory. The resource is closed once the subscription ends, whether it completes, errors, panics
+// or the consumer stops early. The error returned by Close is emitted if the Observable completes.
+func Using[R io.Closer, T any](resourceFactory func() (R, error), observableFactory func(resource R) Observable[T]) Observable[T] {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		var zero T
+		resource, err := resourceFactory()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		closed := false
+		defer func() {
+			if !closed {
+				resource.Close()
+			}
+		}()
+
+		for v, err := range observableFactory(resource).SubscribeContext(ctx) {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		closed = true
+		if err := resource.Close(); err != nil {
+			yield(zero, err)
+		}
+	})
+}
+
 // Empty creates an Observable that emits no items to the Observer and immediately completes.
 func Empty[T any]() Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {})
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {})
 }
 
 // Interval creates an Observable that emits a sequence of integers spaced by a given time interval.
-func Interval(duration time.Duration) Observable[int] {
-	return (ObservableFunc[int])(func(yield func(int, error) bool) {
-		var i int
+// The optional clock overrides the Clock of the pipeline.
+func Interval(duration time.Duration, clock ...Clock) Observable[int] {
+	return (ObservableContextFunc[int])(func(ctx context.Context, yield func(int, error) bool) {
+		ticker := clockOf(ctx, clock).NewTicker(duration)
+		defer ticker.Stop()
 
+		var i int
 		for {
-			<-time.After(duration)
-			if !yield(i, nil) {
+			select {
+			case <-ctx.Done():
 				return
+			case <-ticker.C():
+				if !yield(i, nil) {
+					return
+				}
+				i++
 			}
-			i++
 		}
 	})
 }
@@ -47,7 +92,7 @@ func Interval(duration time.Duration) Observable[int] {
 //
 //	rx.From[int]([]int{1, 2, 3})
 func From[T any, V Iterator[T]](items V) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 		switch vi := any(items).(type) {
 		case []T:
 			for _, v := range vi {
@@ -56,17 +101,9 @@ func From[T any, V Iterator[T]](items V) Observable[T] {
 				}
 			}
 		case chan T:
-			for v := range vi {
-				if !yield(v, nil) {
-					return
-				}
-			}
+			fromChannel(ctx, vi, yield)
 		case <-chan T:
-			for v := range vi {
-				if !yield(v, nil) {
-					return
-				}
-			}
+			fromChannel(ctx, vi, yield)
 		case iter.Seq[T]:
 			for v := range vi {
 				if !yield(v, nil) {
@@ -94,13 +131,25 @@ func From[T any, V Iterator[T]](items V) Observable[T] {
 func FromChannel[T any, C interface {
 	chan T | <-chan T
 }](items C) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
-		for v := range items {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		fromChannel(ctx, items, yield)
+	})
+}
+
+func fromChannel[T any](ctx context.Context, ch <-chan T, yield func(T, error) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-ch:
+			if !ok {
+				return
+			}
 			if !yield(v, nil) {
 				return
 			}
 		}
-	})
+	}
 }
 
 // Of converts the arguments to an observable sequence.
@@ -109,7 +158,7 @@ func FromChannel[T any, C interface {
 //
 //	rx.Of(1, 2, 3)
 func Of[T any](items ...T) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 		for _, v := range items {
 			if !yield(v, nil) {
 				return
@@ -120,7 +169,7 @@ func Of[T any](items ...T) Observable[T] {
 
 // Range creates an Observable that emits a sequence of numbers within a specified range.
 func Range[T Number](start, count T) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 		for ; start <= count; start++ {
 			if !yield(start, nil) {
 				return
@@ -131,43 +180,50 @@ func Range[T Number](start, count T) Observable[T] {
 
 // ThrowError creates an Observable that emits no items to the Observer and immediately emits an error notification.
 func ThrowError[T any](errFactory func() error) Observable[T] {
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 		var zero T
 		yield(zero, errFactory())
 	})
 }
 
 // Timer creates an Observable that starts emitting after an `initialDelay` and emits increasing numbers after each `period` of time thereafter.
-func Timer[N Number](duration time.Duration) Observable[N] {
-	return (ObservableFunc[N])(func(yield func(N, error) bool) {
-		<-time.After(duration)
-		var zero N
-		yield(zero, nil)
+// The optional clock overrides the Clock of the pipeline.
+func Timer[N Number](duration time.Duration, clock ...Clock) Observable[N] {
+	return (ObservableContextFunc[N])(func(ctx context.Context, yield func(N, error) bool) {
+		timer := clockOf(ctx, clock).NewTimer(duration)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+		case <-timer.C():
+			var zero N
+			yield(zero, nil)
+		}
 	})
 }
 
 // Iif decies at subscription time which Observable will actually be subscribed.
 func Iif[A, B any](condition func() bool, trueResult Observable[A], falseResult Observable[B]) Observable[Either[A, B]] {
-	return (ObservableFunc[Either[A, B]])(func(yield func(Either[A, B], error) bool) {
+	return (ObservableContextFunc[Either[A, B]])(func(ctx context.Context, yield func(Either[A, B], error) bool) {
 		if condition() {
-			for v, err := range trueResult.Subscribe() {
+			for v, err := range trueResult.SubscribeContext(ctx) {
 				if err != nil {
 					yield(Either[A, B]{}, err)
 					return
 				}
-				if !yield(Either[A, B]{v: v}, nil) {
+				if !yield(NewA[B](v), nil) {
 					return
 				}
 			}
 			return
 		}
 
-		for v, err := range falseResult.Subscribe() {
+		for v, err := range falseResult.SubscribeContext(ctx) {
 			if err != nil {
 				yield(Either[A, B]{}, err)
 				return
 			}
-			if !yield(Either[A, B]{v: v}, nil) {
+			if !yield(NewB[A](v), nil) {
 				return
 			}
 		}
@@ -1,18 +1,23 @@
 package rx
 
 import (
+	"context"
 	"iter"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
 )
 
 // CatchError catches errors on the source Observable and returns a new Observable or the same Observable.
 func CatchError[T any](selector func(error) Observable[T]) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			next, stop := iter.Pull2(input.SubscribeContext(ctx))
 			defer stop()
 
 			iter2 := func(err error) {
-				next2, stop2 := iter.Pull2(selector(err).Subscribe())
+				next2, stop2 := iter.Pull2(selector(err).SubscribeContext(ctx))
 				defer stop2()
 
 				for {
@@ -52,12 +57,12 @@ func CatchError[T any](selector func(error) Observable[T]) OperatorFunc[T, T] {
 // CatchError2 is similar to CatchError but returns an Either type.
 func CatchError2[I, O any](selector func(error) Observable[O]) OperatorFunc[I, Either[I, O]] {
 	return func(input Observable[I]) Observable[Either[I, O]] {
-		return (ObservableFunc[Either[I, O]])(func(yield func(Either[I, O], error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
+		return (ObservableContextFunc[Either[I, O]])(func(ctx context.Context, yield func(Either[I, O], error) bool) {
+			next, stop := iter.Pull2(input.SubscribeContext(ctx))
 			defer stop()
 
 			iter2 := func(err error) {
-				next2, stop2 := iter.Pull2(selector(err).Subscribe())
+				next2, stop2 := iter.Pull2(selector(err).SubscribeContext(ctx))
 				defer stop2()
 
 				for {
@@ -68,7 +73,7 @@ func CatchError2[I, O any](selector func(error) Observable[O]) OperatorFunc[I, E
 					} else if !ok2 {
 						return
 					} else {
-						if !yield(Either[I, O]{v: v2}, nil) {
+						if !yield(NewB[I](v2), nil) {
 							return
 						}
 					}
@@ -83,7 +88,7 @@ func CatchError2[I, O any](selector func(error) Observable[O]) OperatorFunc[I, E
 				} else if !ok {
 					return
 				} else {
-					if !yield(Either[I, O]{v: v}, nil) {
+					if !yield(NewA[O](v), nil) {
 						return
 					}
 				}
@@ -93,10 +98,11 @@ func CatchError2[I, O any](selector func(error) Observable[O]) OperatorFunc[I, E
 }
 
 // Retry resubscribes to the source Observable a specified number of times if it signals an error.
+// It resubscribes immediately, use RetryWithConfig to wait between the attempts.
 func Retry[T any](count int) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			next, stop := iter.Pull2(input.SubscribeContext(ctx))
 			defer stop()
 
 			var retryCount int
@@ -106,10 +112,10 @@ func Retry[T any](count int) OperatorFunc[T, T] {
 				if err != nil {
 					if count < 0 /* Infinite retry */ {
 						stop()
-						next, stop = iter.Pull2(input.Subscribe())
+						next, stop = iter.Pull2(input.SubscribeContext(ctx))
 					} else if retryCount < count {
 						stop()
-						next, stop = iter.Pull2(input.Subscribe())
+						next, stop = iter.Pull2(input.SubscribeContext(ctx))
 						retryCount++
 					} else {
 						var zero T
@@ -128,12 +134,194 @@ func Retry[T any](count int) OperatorFunc[T, T] {
 	}
 }
 
+// Jitter is the randomisation of the delays between the attempts of RetryWithConfig.
+type Jitter int
+
+const (
+	// NoJitter waits the exponential delay as is.
+	NoJitter Jitter = iota
+	// FullJitter waits a random delay between zero and the exponential delay.
+	FullJitter
+	// DecorrelatedJitter waits a random delay between InitialDelay and three times the previous delay.
+	DecorrelatedJitter
+)
+
+// RetryConfig configures RetryWithConfig.
+type RetryConfig struct {
+	// MaxRetries is the number of resubscriptions, the source is resubscribed forever if it's negative.
+	MaxRetries int
+	// InitialDelay is the delay before the first resubscription, the source is resubscribed immediately if it's zero.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between the attempts, if it's greater than zero.
+	MaxDelay time.Duration
+	// Multiplier is the growth of the delay after each attempt, it defaults to 2.
+	Multiplier float64
+	// Jitter randomises the delays, so the subscribers failing together don't retry together.
+	Jitter Jitter
+	// Retryable reports whether err is worth a retry, every error is retried if it's nil.
+	Retryable func(err error) bool
+	// ResetOnSuccess resets the number of retries and the delay each time the source emits a value.
+	ResetOnSuccess bool
+	// Rand returns a random number in [0.0, 1.0) for the jitter, it defaults to rand.Float64.
+	Rand func() float64
+}
+
+// backoff computes the delays of RetryWithConfig.
+type backoff struct {
+	config  RetryConfig
+	retries int
+	delay   time.Duration
+}
+
+func (b *backoff) reset() {
+	b.retries, b.delay = 0, 0
+}
+
+// next returns the delay before the next attempt, or false if err mustn't be retried.
+func (b *backoff) next(err error) (time.Duration, bool) {
+	if b.config.MaxRetries >= 0 && b.retries >= b.config.MaxRetries {
+		return 0, false
+	}
+	if b.config.Retryable != nil && !b.config.Retryable(err) {
+		return 0, false
+	}
+
+	random := rand.Float64
+	if b.config.Rand != nil {
+		random = b.config.Rand
+	}
+	multiplier := b.config.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	initial := float64(b.config.InitialDelay)
+
+	var delay float64
+	switch b.config.Jitter {
+	case DecorrelatedJitter:
+		previous := float64(b.delay)
+		if b.retries == 0 {
+			previous = initial
+		}
+		delay = initial + random()*(previous*3-initial)
+	default:
+		delay = initial * math.Pow(multiplier, float64(b.retries))
+		if b.config.Jitter == FullJitter {
+			delay *= random()
+		}
+	}
+	if b.config.MaxDelay > 0 {
+		delay = min(delay, float64(b.config.MaxDelay))
+	}
+	// float64(math.MaxInt64) is 2^63, which doesn't fit in a Duration.
+	if delay >= float64(math.MaxInt64) {
+		b.delay = math.MaxInt64
+	} else {
+		b.delay = time.Duration(delay)
+	}
+	b.retries++
+	return b.delay, true
+}
+
+// RetryWithConfig resubscribes to the source Observable if it signals an error, waiting an exponential delay between the attempts.
+// The last error is emitted once the retries are exhausted or the error isn't retryable.
+// The optional clock overrides the Clock of the pipeline.
+func RetryWithConfig[T any](config RetryConfig, clock ...Clock) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			c := clockOf(ctx, clock)
+			b := &backoff{config: config}
+			for {
+				var failure error
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						failure = err
+						break
+					}
+					if config.ResetOnSuccess {
+						b.reset()
+					}
+					if !yield(v, nil) {
+						return
+					}
+				}
+				if failure == nil || ctx.Err() != nil {
+					return
+				}
+
+				delay, ok := b.next(failure)
+				if !ok {
+					var zero T
+					yield(zero, failure)
+					return
+				}
+				if delay > 0 {
+					timer := c.NewTimer(delay)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return
+					case <-timer.C():
+					}
+				}
+			}
+		})
+	}
+}
+
+// RetryWhen resubscribes to the source Observable each time the Observable returned by notifier emits.
+// The notifier is called once with the Observable of the errors of the source, so it decides when and whether to retry:
+// the output Observable completes when it completes, and errors when it errors.
+func RetryWhen[T, N any](notifier func(errors Observable[error]) Observable[N]) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			errs := newQueue[error](0)
+			defer errs.close(nil)
+			notifications := subscribeAsync(ctx, &wg, notifier(queueObservable[error]{errs}))
+
+			for {
+				var failure error
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						failure = err
+						break
+					}
+					if !yield(v, nil) {
+						return
+					}
+				}
+				if failure == nil || !errs.push(ctx, failure) {
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-notifications:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
 // ThrowIfEmpty returns an error if the source Observable completes without emitting any value.
 func ThrowIfEmpty[T comparable](fn ...func() error) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var emptyValue T
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
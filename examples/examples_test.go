@@ -1,12 +1,24 @@
 package main_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/synctest"
 	"time"
 
 	"github.com/si3nloong/rx"
+	"github.com/si3nloong/rx/rxtest"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 )
@@ -22,6 +34,88 @@ func TestBuffer(t *testing.T) {
 		{4, 5},
 		{9},
 	})
+
+	t.Run("BufferCount with startBufferEvery", func(t *testing.T) {
+		assertItems(t, rx.Pipe1(rx.Range(1, 5), rx.BufferCount[int](3, 1)), [][]int{
+			{1, 2, 3},
+			{2, 3, 4},
+			{3, 4, 5},
+			{4, 5},
+			{5},
+		})
+		assertItems(t, rx.Pipe1(rx.Range(1, 7), rx.BufferCount[int](2, 3)), [][]int{
+			{1, 2},
+			{4, 5},
+			{7},
+		})
+	})
+
+	t.Run("BufferCount without buffer size", func(t *testing.T) {
+		assertItems(t, rx.Pipe1(rx.Range(1, 3), rx.BufferCount[int](0)), [][]int{
+			{1},
+			{2},
+			{3},
+		})
+	})
+
+	buffers := map[string][]string{
+		"x": {"a", "b"},
+		"y": {"c", "d"},
+		"z": {"e"},
+	}
+
+	t.Run("BufferTime", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferTime[string](4*rxtest.Frame))).
+				ToBe("----x---y-(z|)", buffers, nil)
+		})
+	})
+
+	t.Run("BufferTime with MaxSize", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferTime[string](5*rxtest.Frame, rx.WindowTimeOptions{MaxSize: 2}))).
+				ToBe("---x---y--(z|)", buffers, nil)
+		})
+	})
+
+	t.Run("BufferTime with CreationInterval", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferTime[string](2*rxtest.Frame, rx.WindowTimeOptions{CreationInterval: 4 * rxtest.Frame}))).
+				ToBe("--x---y---(z|)", map[string][]string{"x": {"a"}, "y": {"c"}, "z": {"e"}}, nil)
+		})
+	})
+
+	t.Run("BufferTimeOrCount", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-----c---|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferTimeOrCount[string](3*rxtest.Frame, 2))).
+				ToBe("---x--------y|", map[string][]string{"x": {"a", "b"}, "y": {"c"}}, nil)
+		})
+	})
+
+	t.Run("BufferToggle", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			openings := rxtest.Cold[string](s, "--o---o|", nil, nil)
+			closing := rxtest.Cold[string](s, "--x", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferToggle[string](openings, func(string) rx.Observable[string] {
+				return closing
+			}))).ToBe("----x---y-|", map[string][]string{"x": {"b"}, "y": {"d"}}, nil)
+		})
+	})
+
+	t.Run("BufferWhen", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			closing := rxtest.Cold[string](s, "----x", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.BufferWhen[string](func() rx.Observable[string] {
+				return closing
+			}))).ToBe("----x---y-(z|)", buffers, nil)
+		})
+	})
 }
 
 func TestCombineLatest(t *testing.T) {
@@ -39,205 +133,2307 @@ func TestCombineLatest(t *testing.T) {
 	})
 }
 
-func TestCatchError(t *testing.T) {
+func TestCombineLatestN(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("CombineLatest2", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			a := rxtest.Cold[string](s, "-a---b----|", nil, nil)
+			b := rxtest.Cold(s, "---x---y-|", map[string]int{"x": 1, "y": 2}, nil)
+			rxtest.ExpectObservable(s, rx.CombineLatest2(a, b)).ToBe("---x-y-z--|", map[string]rx.Tuple[string, int]{
+				"x": rx.NewTuple("a", 1),
+				"y": rx.NewTuple("b", 1),
+				"z": rx.NewTuple("b", 2),
+			}, nil)
+		})
+	})
+
+	t.Run("CombineLatest3", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			a := rxtest.Cold[string](s, "-a--|", nil, nil)
+			b := rxtest.Cold(s, "--x-|", map[string]int{"x": 1}, nil)
+			c := rxtest.Cold(s, "---t|", map[string]bool{"t": true}, nil)
+			rxtest.ExpectObservable(s, rx.CombineLatest3(a, b, c)).ToBe("---x|", map[string]rx.Tuple3[string, int, bool]{
+				"x": rx.NewTuple3("a", 1, true),
+			}, nil)
+		})
+	})
+
+	t.Run("Nil values", func(t *testing.T) {
+		assertItem(t, rx.CombineLatest2(rx.Of[fmt.Stringer](nil), rx.Of(1)), []rx.Tuple[fmt.Stringer, int]{
+			rx.NewTuple[fmt.Stringer](nil, 1),
+		})
+		assertItem(t, rx.CombineLatest3(rx.Of[error](nil), rx.Of[*int](nil), rx.Of[any](nil)), []rx.Tuple3[error, *int, any]{
+			rx.NewTuple3[error, *int, any](nil, nil, nil),
+		})
+	})
+}
+
+func TestWithLatestFrom(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("WithLatestFrom", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a---b---c-|", nil, nil)
+			other := rxtest.Cold(s, "--x-----y|", map[string]int{"x": 1, "y": 2}, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.WithLatestFrom[string](other))).
+				ToBe("-----b---c-|", map[string]rx.Tuple[string, int]{
+					"b": rx.NewTuple("b", 1),
+					"c": rx.NewTuple("c", 2),
+				}, nil)
+			s.ExpectSubscriptions(other).ToBe("^--------!")
+		})
+	})
+
+	t.Run("WithLatestFrom2", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a---b-|", nil, nil)
+			a := rxtest.Cold(s, "--x", map[string]int{"x": 1}, nil)
+			b := rxtest.Cold(s, "---y", map[string]bool{"y": true}, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.WithLatestFrom2[string](a, b))).
+				ToBe("-----b-|", map[string]rx.Tuple3[string, int, bool]{
+					"b": rx.NewTuple3("b", 1, true),
+				}, nil)
+		})
+	})
+
+	t.Run("WithLatestFrom error", func(t *testing.T) {
+		err := errors.New("failed")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a---b-|", nil, nil)
+			other := rxtest.Cold[int](s, "--#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.WithLatestFrom[string](other))).ToBe("--#", nil, err)
+		})
+	})
+
+	t.Run("Nil values", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "--a-|", nil, nil)
+			a := rxtest.Cold(s, "x", map[string]fmt.Stringer{"x": nil}, nil)
+			b := rxtest.Cold(s, "-y", map[string]*int{"y": nil}, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.WithLatestFrom2[string](a, b))).
+				ToBe("--a-|", map[string]rx.Tuple3[string, fmt.Stringer, *int]{
+					"a": rx.NewTuple3[string, fmt.Stringer, *int]("a", nil, nil),
+				}, nil)
+		})
+	})
+}
+
+func TestZip(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Zip", func(t *testing.T) {
+		assertItems(t, rx.Zip(
+			rx.Of(1, 2, 3, 4),
+			rx.Of(10, 20, 30),
+			rx.Pipe1(rx.Interval(time.Millisecond), rx.Map(func(v int, _ int) int { return v * 100 })),
+		), [][]int{
+			{1, 10, 0},
+			{2, 20, 100},
+			{3, 30, 200},
+		})
+	})
+
+	t.Run("Zip with empty input", func(t *testing.T) {
+		assertItems(t, rx.Zip(rx.Empty[int](), rx.Interval(time.Millisecond)), [][]int{})
+	})
+
+	t.Run("Zip with error", func(t *testing.T) {
+		err := errors.New("failed")
+		isError(t, rx.Zip(
+			rx.Interval(time.Millisecond),
+			rx.Pipe1(rx.ThrowError[int](func() error { return err }), rx.Delay[int](time.Millisecond*5)),
+		), err)
+	})
+
+	t.Run("Zip2", func(t *testing.T) {
+		assertItem(t, rx.Zip2(
+			rx.Of("a", "b", "c"),
+			rx.Of(1, 2),
+		), []rx.Tuple[string, int]{
+			rx.NewTuple("a", 1),
+			rx.NewTuple("b", 2),
+		})
+	})
+
+	t.Run("Zip3", func(t *testing.T) {
+		assertItem(t, rx.Zip3(
+			rx.Of("a", "b"),
+			rx.Of(1, 2),
+			rx.Of(true, false),
+		), []rx.Tuple3[string, int, bool]{
+			rx.NewTuple3("a", 1, true),
+			rx.NewTuple3("b", 2, false),
+		})
+	})
+
+	t.Run("Zip4", func(t *testing.T) {
+		assertItem(t, rx.Zip4(
+			rx.Of("a"),
+			rx.Of(1),
+			rx.Of(true),
+			rx.Interval(time.Millisecond),
+		), []rx.Tuple4[string, int, bool, int]{
+			rx.NewTuple4("a", 1, true, 0),
+		})
+	})
+
+	t.Run("Nil values", func(t *testing.T) {
+		assertItem(t, rx.Zip2(
+			rx.Of[error](nil),
+			rx.Of[*int](nil),
+		), []rx.Tuple[error, *int]{
+			rx.NewTuple[error, *int](nil, nil),
+		})
+		assertItem(t, rx.Zip4(
+			rx.Of[fmt.Stringer](nil),
+			rx.Of[any](nil),
+			rx.Of[[]int](nil),
+			rx.Of(1),
+		), []rx.Tuple4[fmt.Stringer, any, []int, int]{
+			rx.NewTuple4[fmt.Stringer, any, []int](nil, nil, nil, 1),
+		})
+	})
+}
+
+func TestCatchError(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe3(
+		rx.Range(1, 20),
+		rx.Filter(func(v int) bool {
+			return v%2 == 0
+		}),
+		rx.MapErr(func(v int, _ int) (int, error) {
+			if v > 10 {
+				return 0, errors.New(`stop la`)
+			}
+			return v, nil
+		}),
+		rx.CatchError2[int](func(err error) rx.Observable[string] {
+			return rx.From[string]([]string{"I", "II", "III", "IV", "V"})
+		}),
+	), []rx.Either[int, string]{
+		rx.NewA[string](2),
+		rx.NewA[string](4),
+		rx.NewA[string](6),
+		rx.NewA[string](8),
+		rx.NewA[string](10),
+		rx.NewB[int]("I"),
+		rx.NewB[int]("II"),
+		rx.NewB[int]("III"),
+		rx.NewB[int]("IV"),
+		rx.NewB[int]("V"),
+	})
+}
+
+func TestRetry(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	err := errors.New("failed")
+
+	t.Run("Exponential backoff", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWithConfig[string](rx.RetryConfig{
+				MaxRetries:   2,
+				InitialDelay: 2 * rxtest.Frame,
+			}))).ToBe("-a---a-----a#", nil, err)
+			s.ExpectSubscriptions(source).ToBe("^-!", "----^-!", "----------^-!")
+		})
+	})
+
+	t.Run("Max delay", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWithConfig[string](rx.RetryConfig{
+				MaxRetries:   3,
+				InitialDelay: 2 * rxtest.Frame,
+				MaxDelay:     3 * rxtest.Frame,
+			}))).ToBe("-a---a----a----a#", nil, err)
+		})
+	})
+
+	t.Run("Full jitter", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWithConfig[string](rx.RetryConfig{
+				MaxRetries:   2,
+				InitialDelay: 4 * rxtest.Frame,
+				Jitter:       rx.FullJitter,
+				Rand:         func() float64 { return 0.5 },
+			}))).ToBe("-a---a-----a#", nil, err)
+		})
+	})
+
+	t.Run("Decorrelated jitter", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			// The delays are 2+0.5*(6-2) = 4, then min(2+0.5*(12-2), 5) = 5.
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWithConfig[string](rx.RetryConfig{
+				MaxRetries:   2,
+				InitialDelay: 2 * rxtest.Frame,
+				MaxDelay:     5 * rxtest.Frame,
+				Jitter:       rx.DecorrelatedJitter,
+				Rand:         func() float64 { return 0.5 },
+			}))).ToBe("-a-----a------a#", nil, err)
+		})
+	})
+
+	t.Run("Saturated delay", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			scheduler := rx.NewTestScheduler(synctest.Wait)
+			var attempts []time.Time
+			source := rx.Defer(func() rx.Observable[int] {
+				attempts = append(attempts, scheduler.Now())
+				return rx.ThrowError[int](func() error { return err })
+			})
+
+			done := make(chan error)
+			go func() {
+				_, e := rx.LastValueFrom(context.Background(), rx.Pipe1(source, rx.RetryWithConfig[int](rx.RetryConfig{
+					MaxRetries:   4,
+					InitialDelay: time.Hour,
+					Multiplier:   1e4,
+				}, scheduler)))
+				done <- e
+			}()
+			scheduler.Flush()
+			require.ErrorIs(t, <-done, err)
+
+			// The delays grow until they saturate, they never wrap around to a negative delay.
+			delays := make([]time.Duration, 0, len(attempts)-1)
+			for i := 1; i < len(attempts); i++ {
+				delays = append(delays, attempts[i].Sub(attempts[i-1]))
+			}
+			require.Equal(t, []time.Duration{time.Hour, 1e4 * time.Hour, math.MaxInt64, math.MaxInt64}, delays)
+		})
+	})
+
+	t.Run("Retryable", func(t *testing.T) {
+		fatal := errors.New("fatal")
+		var attempts int
+		isError(t, rx.Pipe1(rx.Defer(func() rx.Observable[int] {
+			attempts++
+			if attempts < 3 {
+				return rx.ThrowError[int](func() error { return err })
+			}
+			return rx.ThrowError[int](func() error { return fatal })
+		}), rx.RetryWithConfig[int](rx.RetryConfig{
+			MaxRetries: -1,
+			Retryable:  func(err error) bool { return !errors.Is(err, fatal) },
+		})), fatal)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("Reset on success", func(t *testing.T) {
+		source := func() rx.Observable[int] {
+			var attempts int
+			return rx.Defer(func() rx.Observable[int] {
+				attempts++
+				switch attempts {
+				case 1:
+					return rx.ThrowError[int](func() error { return err })
+				case 2:
+					return rx.Concat(rx.Of(1), rx.ThrowError[int](func() error { return err }))
+				default:
+					return rx.Of(2)
+				}
+			})
+		}
+
+		assertItem(t, rx.Pipe1(source(), rx.RetryWithConfig[int](rx.RetryConfig{
+			MaxRetries:     1,
+			ResetOnSuccess: true,
+		})), []int{1, 2})
+
+		var result []int
+		for v, e := range rx.Pipe1(source(), rx.RetryWithConfig[int](rx.RetryConfig{MaxRetries: 1})).Subscribe() {
+			if e != nil {
+				require.ErrorIs(t, e, err)
+				break
+			}
+			result = append(result, v)
+		}
+		require.Equal(t, []int{1}, result)
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		isErrorContext(t, ctx, rx.Pipe1(rx.ThrowError[int](func() error { return err }), rx.RetryWithConfig[int](rx.RetryConfig{
+			MaxRetries:   -1,
+			InitialDelay: time.Hour,
+		})), context.DeadlineExceeded)
+	})
+
+	t.Run("RetryWhen", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWhen[string](func(errs rx.Observable[error]) rx.Observable[error] {
+				return rx.Pipe1(errs, rx.Take[error](2))
+			}))).ToBe("-a-a-a|", nil, nil)
+			s.ExpectSubscriptions(source).ToBe("^-!", "--^-!", "----^-!")
+		})
+	})
+
+	t.Run("RetryWhen error", func(t *testing.T) {
+		giveUp := errors.New("give up")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RetryWhen[string](func(errs rx.Observable[error]) rx.Observable[int] {
+				return rx.Pipe1(errs, rx.MapErr(func(_ error, i int) (int, error) {
+					if i > 0 {
+						return 0, giveUp
+					}
+					return i, nil
+				}))
+			}))).ToBe("-a-a#", nil, giveUp)
+		})
+	})
+}
+
+func TestRepeat(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Repeat", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(rx.Of(1, 2), rx.Repeat[int](3)), []int{1, 2, 1, 2, 1, 2})
+		assertItem(t, rx.Pipe1(rx.Of(1, 2), rx.Repeat[int](0)), []int{})
+		assertItem(t, rx.Pipe2(rx.Of(1), rx.Repeat[int](-1), rx.Take[int](4)), []int{1, 1, 1, 1})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.Repeat[string](3))).ToBe("-a#", nil, err)
+			s.ExpectSubscriptions(source).ToBe("^-!")
+		})
+	})
+
+	t.Run("RepeatWithDelay", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RepeatWithDelay[string](3, 2*rxtest.Frame))).ToBe("-a---a---a|", nil, nil)
+			s.ExpectSubscriptions(source).ToBe("^-!", "----^-!", "--------^-!")
+		})
+	})
+
+	t.Run("RepeatWhen", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RepeatWhen[string](func(completions rx.Observable[struct{}]) rx.Observable[struct{}] {
+				return rx.Pipe1(completions, rx.Take[struct{}](2))
+			}))).ToBe("-a-a-a|", nil, nil)
+			s.ExpectSubscriptions(source).ToBe("^-!", "--^-!", "----^-!")
+		})
+	})
+
+	t.Run("Polling", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			var polls int
+			source := rx.Defer(func() rx.Observable[int] {
+				polls++
+				return rx.Pipe1(rx.Timer[int](rxtest.Frame), rx.Map(func(int, int) int { return polls }))
+			})
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.RepeatWhen[int](func(completions rx.Observable[struct{}]) rx.Observable[int] {
+				return rx.Pipe2(completions, rx.Take[struct{}](2), rx.MergeMap(func(struct{}, int) rx.Observable[int] {
+					return rx.Timer[int](2 * rxtest.Frame)
+				}))
+			}))).ToBe("-a--b--(c|)", map[string]int{"a": 1, "b": 2, "c": 3}, nil)
+		})
+	})
+}
+
+func TestFinalize(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	err := errors.New("failed")
+
+	t.Run("Finalize", func(t *testing.T) {
+		var calls int
+		assertItem(t, rx.Pipe1(rx.Of(1, 2), rx.Finalize[int](func() { calls++ })), []int{1, 2})
+		require.Equal(t, 1, calls)
+
+		isError(t, rx.Pipe1(rx.ThrowError[int](func() error { return err }), rx.Finalize[int](func() { calls++ })), err)
+		require.Equal(t, 2, calls)
+
+		for range rx.Pipe1(rx.Of(1, 2, 3), rx.Finalize[int](func() { calls++ })).Subscribe() {
+			break
+		}
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		var calls int
+		require.Panics(t, func() {
+			for range rx.Pipe2(rx.Of(1, 2), rx.Finalize[int](func() { calls++ }), rx.Map(func(int, int) int { panic("boom") })).Subscribe() {
+			}
+		})
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("TapWith", func(t *testing.T) {
+		var events []string
+		observer := rx.TapObserver[int]{
+			Next:        func(v int) { events = append(events, fmt.Sprintf("next(%d)", v)) },
+			Error:       func(err error) { events = append(events, "error") },
+			Complete:    func() { events = append(events, "complete") },
+			Subscribe:   func() { events = append(events, "subscribe") },
+			Unsubscribe: func() { events = append(events, "unsubscribe") },
+		}
+
+		assertItem(t, rx.Pipe1(rx.Of(1, 2), rx.TapWith(observer)), []int{1, 2})
+		require.Equal(t, []string{"subscribe", "next(1)", "next(2)", "complete"}, events)
+
+		events = nil
+		isError(t, rx.Pipe1(rx.Concat(rx.Of(1), rx.ThrowError[int](func() error { return err })), rx.TapWith(observer)), err)
+		require.Equal(t, []string{"subscribe", "next(1)", "error"}, events)
+
+		events = nil
+		for range rx.Pipe1(rx.Of(1, 2, 3), rx.TapWith(observer)).Subscribe() {
+			break
+		}
+		require.Equal(t, []string{"subscribe", "next(1)", "unsubscribe"}, events)
+
+		events = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, err := range rx.Pipe1(rx.Of(1, 2, 3), rx.TapWith(observer)).SubscribeContext(ctx) {
+			if err != nil {
+				require.ErrorIs(t, err, context.Canceled)
+				break
+			}
+			cancel()
+		}
+		require.Equal(t, []string{"subscribe", "next(1)", "unsubscribe"}, events)
+	})
+
+	t.Run("Partial TapWith", func(t *testing.T) {
+		var completed bool
+		assertItem(t, rx.Pipe1(rx.Of(1), rx.TapWith(rx.TapObserver[int]{Complete: func() { completed = true }})), []int{1})
+		require.True(t, completed)
+	})
+}
+
+func TestDefaultIfEmpty(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe1(
+		rx.Empty[string](),
+		rx.DefaultIfEmpty(`hello world!`),
+	), []string{`hello world!`})
+}
+
+func TestSubscribeContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		result := make([]int, 0)
+		var lastErr error
+		for v, err := range rx.Pipe1(
+			rx.Interval(time.Millisecond),
+			rx.Map(func(v int, _ int) int { return v * 2 }),
+		).SubscribeContext(ctx) {
+			if err != nil {
+				lastErr = err
+				break
+			}
+			result = append(result, v)
+			if len(result) == 3 {
+				cancel()
+			}
+		}
+		require.ErrorIs(t, lastErr, context.Canceled)
+		require.Equal(t, []int{0, 2, 4}, result)
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		isErrorContext(t, ctx, rx.Merge(
+			rx.Interval(time.Hour),
+			rx.Pipe1(rx.Of(1), rx.Delay[int](time.Hour)),
+		), context.DeadlineExceeded)
+	})
+}
+
+func TestSubscribeAsync(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Complete", func(t *testing.T) {
+		var (
+			result    []int
+			completed bool
+		)
+		sub := rx.SubscribeAsync(context.Background(), rx.Of(1, 2, 3), func(v int) {
+			result = append(result, v)
+		}, func(err error) {
+			t.Fatal(err)
+		}, func() {
+			completed = true
+		})
+		<-sub.Done()
+		require.NoError(t, sub.Err())
+		require.Equal(t, []int{1, 2, 3}, result)
+		require.True(t, completed)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		var failure error
+		sub := rx.SubscribeAsync(context.Background(), rx.ThrowError[int](func() error { return err }), nil, func(err error) {
+			failure = err
+		}, nil)
+		<-sub.Done()
+		require.ErrorIs(t, sub.Err(), err)
+		require.ErrorIs(t, failure, err)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		received := make(chan int, 1)
+		sub := rx.SubscribeAsync(context.Background(), rx.Interval(time.Millisecond), func(v int) {
+			select {
+			case received <- v:
+			default:
+			}
+		}, func(err error) {
+			t.Error("unexpected error", err)
+		}, func() {
+			t.Error("unexpected completion")
+		})
+		require.NoError(t, sub.Err())
+		<-received
+		sub.Unsubscribe()
+		<-sub.Done()
+		require.ErrorIs(t, sub.Err(), context.Canceled)
+	})
+
+	t.Run("CompositeSubscription", func(t *testing.T) {
+		var subs rx.CompositeSubscription
+		a := rx.SubscribeAsync(context.Background(), rx.Interval(time.Millisecond), nil, nil, nil)
+		b := rx.SubscribeAsync(context.Background(), rx.Interval(time.Hour), nil, nil, nil)
+		subs.Add(a, b)
+		require.Equal(t, 2, subs.Len())
+
+		subs.Unsubscribe()
+		require.NoError(t, subs.Wait(context.Background()))
+		require.ErrorIs(t, a.Err(), context.Canceled)
+		require.ErrorIs(t, b.Err(), context.Canceled)
+
+		// A Subscription added once unsubscribed is unsubscribed right away.
+		c := rx.SubscribeAsync(context.Background(), rx.Interval(time.Hour), nil, nil, nil)
+		subs.Add(c)
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), context.Canceled)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		var subs rx.CompositeSubscription
+		a := rx.SubscribeAsync(context.Background(), rx.Interval(time.Hour), nil, nil, nil)
+		subs.Add(a)
+		subs.Remove(a)
+		subs.Unsubscribe()
+		require.Equal(t, 0, subs.Len())
+		select {
+		case <-a.Done():
+			t.Fatal("removed subscription was unsubscribed")
+		case <-time.After(5 * time.Millisecond):
+		}
+		a.Unsubscribe()
+		<-a.Done()
+	})
+}
+
+type recorder[T any] struct {
+	events []string
+}
+
+func (r *recorder[T]) Next(v T)        { r.events = append(r.events, fmt.Sprintf("next(%v)", v)) }
+func (r *recorder[T]) Error(err error) { r.events = append(r.events, fmt.Sprintf("error(%v)", err)) }
+func (r *recorder[T]) Complete()       { r.events = append(r.events, "complete") }
+
+func TestObserver(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	err := errors.New("failed")
+
+	t.Run("Partial observer", func(t *testing.T) {
+		var result []int
+		rx.SubscribeWith(context.Background(), rx.Of(1, 2), rx.PartialObserver[int]{
+			OnNext: func(v int) { result = append(result, v) },
+		})
+		require.Equal(t, []int{1, 2}, result)
+
+		var failure error
+		rx.SubscribeWith(context.Background(), rx.ThrowError[int](func() error { return err }), rx.PartialObserver[int]{
+			OnError: func(err error) { failure = err },
+		})
+		require.ErrorIs(t, failure, err)
+	})
+
+	t.Run("SubscribeOn with nil callbacks", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			rx.Of(1, 2).SubscribeOn(nil, nil, nil)
+		})
+	})
+
+	t.Run("Reusable observer", func(t *testing.T) {
+		r := &recorder[int]{}
+		rx.SubscribeWith(context.Background(), rx.Of(1, 2), r)
+		rx.SubscribeWith(context.Background(), rx.ThrowError[int](func() error { return err }), r)
+		require.Equal(t, []string{"next(1)", "next(2)", "complete", "error(failed)"}, r.events)
+	})
+
+	t.Run("SubscribeAsyncWith", func(t *testing.T) {
+		r := &recorder[int]{}
+		sub := rx.SubscribeAsyncWith(context.Background(), rx.Of(1), r)
+		<-sub.Done()
+		require.Equal(t, []string{"next(1)", "complete"}, r.events)
+	})
+
+	t.Run("Subject as observer", func(t *testing.T) {
+		subject := rx.NewReplaySubject[int](10, 0)
+		rx.SubscribeWith(context.Background(), rx.Of(1, 2, 3), rx.Observer[int](subject))
+		assertItem(t, subject, []int{1, 2, 3})
+	})
+
+	t.Run("NewSubjectFrom", func(t *testing.T) {
+		r := &recorder[int]{}
+		subject := rx.NewSubjectFrom[int](r)
+
+		var wg sync.WaitGroup
+		var result []int
+		ready := make(chan struct{})
+		wg.Go(func() {
+			next, stop := iter.Pull2(subject.Subscribe())
+			defer stop()
+			close(ready)
+			for {
+				v, err, ok := next()
+				if err != nil || !ok {
+					return
+				}
+				result = append(result, v)
+			}
+		})
+		<-ready
+		for !subject.Observed() {
+			time.Sleep(time.Millisecond)
+		}
+
+		rx.SubscribeWith(context.Background(), rx.Of(1, 2), subject)
+		subject.Next(3)
+		subject.Error(err)
+		wg.Wait()
+
+		require.Equal(t, []string{"next(1)", "next(2)", "complete"}, r.events)
+		require.Equal(t, []int{1, 2}, result)
+	})
+}
+
+func TestSinks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	err := errors.New("failed")
+
+	t.Run("ToChannel", func(t *testing.T) {
+		values, errs := rx.ToChannel(context.Background(), rx.Of(1, 2, 3), 1)
+		var result []int
+		for v := range values {
+			result = append(result, v)
+		}
+		require.Equal(t, []int{1, 2, 3}, result)
+		require.NoError(t, <-errs)
+
+		values, errs = rx.ToChannel(context.Background(), rx.Concat(rx.Of(1), rx.ThrowError[int](func() error { return err })), 0)
+		require.Equal(t, 1, <-values)
+		_, ok := <-values
+		require.False(t, ok)
+		require.ErrorIs(t, <-errs, err)
+	})
+
+	t.Run("ToChannel cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		values, errs := rx.ToChannel(ctx, rx.Interval(time.Millisecond), 0)
+		<-values
+		cancel()
+		for range values {
+		}
+		require.ErrorIs(t, <-errs, context.Canceled)
+	})
+
+	t.Run("ToMap", func(t *testing.T) {
+		words := rx.Of("apple", "avocado", "banana")
+		first := func(v string) byte { return v[0] }
+		assertItem(t, rx.Pipe1(words, rx.ToMap(first)), []map[byte]string{{'a': "avocado", 'b': "banana"}})
+		assertItem(t, rx.Pipe1(words, rx.ToMapOf(first, func(v string) int { return len(v) })), []map[byte]int{{'a': 7, 'b': 6}})
+		assertItem(t, rx.Pipe1(words, rx.ToMultiMap(first)), []map[byte][]string{{'a': {"apple", "avocado"}, 'b': {"banana"}}})
+		isError(t, rx.Pipe1(rx.ThrowError[string](func() error { return err }), rx.ToMap(first)), err)
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		var result []int
+		require.NoError(t, rx.ForEach(context.Background(), rx.Of(1, 2, 3), func(v int) error {
+			result = append(result, v)
+			return nil
+		}))
+		require.Equal(t, []int{1, 2, 3}, result)
+
+		require.ErrorIs(t, rx.ForEach(context.Background(), rx.Of(1, 2, 3), func(v int) error {
+			if v == 2 {
+				return err
+			}
+			return nil
+		}), err)
+		require.ErrorIs(t, rx.ForEach(context.Background(), rx.ThrowError[int](func() error { return err }), func(int) error {
+			return nil
+		}), err)
+	})
+
+	t.Run("ForEach concurrently", func(t *testing.T) {
+		var running, maxRunning, sum atomic.Int64
+		require.NoError(t, rx.ForEach(context.Background(), rx.Range(1, 20), func(v int) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			sum.Add(int64(v))
+			return nil
+		}, 3))
+		require.Equal(t, int64(210), sum.Load())
+		require.LessOrEqual(t, maxRunning.Load(), int64(3))
+
+		// The first error of fn stops the infinite source.
+		require.ErrorIs(t, rx.ForEach(context.Background(), rx.Interval(time.Millisecond), func(v int) error {
+			if v == 3 {
+				return err
+			}
+			return nil
+		}, 2), err)
+	})
+
+	t.Run("FirstValueFrom", func(t *testing.T) {
+		v, e := rx.FirstValueFrom(context.Background(), rx.Interval(time.Millisecond))
+		require.NoError(t, e)
+		require.Equal(t, 0, v)
+
+		_, e = rx.FirstValueFrom(context.Background(), rx.Empty[int]())
+		require.ErrorIs(t, e, rx.ErrEmpty)
+
+		_, e = rx.FirstValueFrom(context.Background(), rx.ThrowError[int](func() error { return err }))
+		require.ErrorIs(t, e, err)
+	})
+
+	t.Run("LastValueFrom", func(t *testing.T) {
+		v, e := rx.LastValueFrom(context.Background(), rx.Of(1, 2, 3))
+		require.NoError(t, e)
+		require.Equal(t, 3, v)
+
+		_, e = rx.LastValueFrom(context.Background(), rx.Empty[int]())
+		require.ErrorIs(t, e, rx.ErrEmpty)
+
+		_, e = rx.LastValueFrom(context.Background(), rx.Concat(rx.Of(1), rx.ThrowError[int](func() error { return err })))
+		require.ErrorIs(t, e, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		_, e = rx.LastValueFrom(ctx, rx.Interval(time.Millisecond))
+		require.ErrorIs(t, e, context.DeadlineExceeded)
+	})
+}
+
+func TestSubject(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Observed", func(t *testing.T) {
+		for _, subject := range []rx.Subject[int]{rx.NewSubject[int](), rx.NewBehaviorSubject(0), rx.NewAsyncSubject[int]()} {
+			require.False(t, subject.Observed())
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for range subject.SubscribeContext(ctx) {
+				}
+			}()
+			for !subject.Observed() {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+			<-done
+			require.False(t, subject.Observed())
+		}
+	})
+
+	t.Run("Subject", func(t *testing.T) {
+		subject := rx.NewSubject[int]()
+		subject.Next(-1)
+
+		var wg sync.WaitGroup
+		var ready atomic.Int32
+		results := make([][]int, 2)
+		for i := range results {
+			wg.Go(func() {
+				var subscribed bool
+				for v, err := range subject.Subscribe() {
+					require.NoError(t, err)
+					if v == 0 {
+						if !subscribed {
+							subscribed = true
+							ready.Add(1)
+						}
+						continue
+					}
+					results[i] = append(results[i], v)
+				}
+			})
+		}
+		// Ping the subscribers until all of them are subscribed.
+		for ready.Load() < int32(len(results)) {
+			subject.Next(0)
+			time.Sleep(time.Millisecond)
+		}
+		subject.Next(1)
+		subject.Next(2)
+		subject.Complete()
+		subject.Next(3)
+		wg.Wait()
+
+		require.Equal(t, [][]int{{1, 2}, {1, 2}}, results)
+	})
+
+	t.Run("BehaviorSubject", func(t *testing.T) {
+		subject := rx.NewBehaviorSubject(1)
+		subject.Next(2)
+		require.Equal(t, 2, subject.Value())
+
+		next, stop := iter.Pull2(subject.Subscribe())
+		defer stop()
+
+		v, err, ok := next()
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 2, v)
+
+		subject.Next(3)
+		v, _, _ = next()
+		require.Equal(t, 3, v)
+
+		subject.Error(errors.New("boom"))
+		_, err, _ = next()
+		require.EqualError(t, err, "boom")
+	})
+
+	t.Run("ReplaySubject", func(t *testing.T) {
+		subject := rx.NewReplaySubject[int](2, 0)
+		subject.Next(1)
+		subject.Next(2)
+		subject.Next(3)
+		subject.Complete()
+
+		assertItem(t, subject, []int{2, 3})
+		assertItem(t, subject, []int{2, 3})
+	})
+
+	t.Run("ReplaySubject with window time", func(t *testing.T) {
+		subject := rx.NewReplaySubject[int](0, 20*time.Millisecond)
+		subject.Next(1)
+		time.Sleep(40 * time.Millisecond)
+		subject.Next(2)
+		subject.Complete()
+
+		assertItem(t, subject, []int{2})
+	})
+
+	t.Run("AsyncSubject", func(t *testing.T) {
+		subject := rx.NewAsyncSubject[int]()
+		subject.Next(1)
+		subject.Next(2)
+		subject.Complete()
+
+		assertItem(t, subject, []int{2})
+	})
+
+	t.Run("Concurrent publishers", func(t *testing.T) {
+		subject := rx.NewReplaySubject[int](0, 0)
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				subject.Next(i)
+			})
+		}
+		wg.Wait()
+		subject.Complete()
+
+		assertItem(t, subject, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	})
+}
+
+func TestShare(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Subscribe while disconnecting", func(t *testing.T) {
+		shared := rx.Pipe1(rx.Interval(time.Microsecond), rx.Share[int]())
+
+		// A subscriber racing with the last one leaving must still be connected to the source.
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for range 100 {
+					values := make([]int, 0)
+					for v, err := range rx.Pipe1(shared, rx.Take[int](1)).Subscribe() {
+						require.NoError(t, err)
+						values = append(values, v)
+					}
+					require.Len(t, values, 1)
+				}
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("Share", func(t *testing.T) {
+		var subscriptions atomic.Int32
+		shared := rx.Pipe1(
+			rx.Defer(func() rx.Observable[int] {
+				subscriptions.Add(1)
+				return rx.Interval(5 * time.Millisecond)
+			}),
+			rx.Share[int](),
+		)
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Go(func() {
+				values := make([]int, 0)
+				for v, err := range rx.Pipe1(shared, rx.Take[int](3)).Subscribe() {
+					require.NoError(t, err)
+					values = append(values, v)
+				}
+				require.Len(t, values, 3)
+			})
+		}
+		wg.Wait()
+		require.LessOrEqual(t, subscriptions.Load(), int32(3))
+
+		// Once every subscriber is gone, the source is subscribed again.
+		before := subscriptions.Load()
+		assertItem(t, rx.Pipe1(shared, rx.Take[int](1)), []int{0})
+		require.Equal(t, before+1, subscriptions.Load())
+	})
+
+	t.Run("ShareReplay", func(t *testing.T) {
+		var subscriptions atomic.Int32
+		shared := rx.Pipe1(
+			rx.Defer(func() rx.Observable[int] {
+				subscriptions.Add(1)
+				return rx.Of(1, 2, 3)
+			}),
+			rx.ShareReplay[int](2, 0),
+		)
+
+		assertItem(t, shared, []int{1, 2, 3})
+		assertItem(t, shared, []int{2, 3})
+		require.Equal(t, int32(1), subscriptions.Load())
+	})
+
+	t.Run("Connectable", func(t *testing.T) {
+		var subscriptions atomic.Int32
+		connectable := rx.NewConnectable(rx.Defer(func() rx.Observable[int] {
+			subscriptions.Add(1)
+			return rx.Interval(time.Millisecond)
+		}), func() rx.Subject[int] {
+			return rx.NewReplaySubject[int](0, 0)
+		})
+
+		disconnect := connectable.Connect()
+		assertItem(t, rx.Pipe1(connectable, rx.Take[int](3)), []int{0, 1, 2})
+		assertItem(t, rx.Pipe1(connectable, rx.Take[int](3)), []int{0, 1, 2})
+		disconnect()
+
+		require.Equal(t, int32(1), subscriptions.Load())
+	})
+}
+
+func TestTestScheduler(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Interval", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			scheduler := rx.NewTestScheduler(synctest.Wait)
+			start := scheduler.Now()
+			ctx := rx.WithClock(context.Background(), scheduler)
+
+			done := make(chan []time.Duration)
+			go func() {
+				result := make([]time.Duration, 0)
+				for v, err := range rx.Pipe2(
+					rx.Interval(time.Hour),
+					rx.Take[int](3),
+					rx.WithTimestamp[int](),
+				).SubscribeContext(ctx) {
+					require.NoError(t, err)
+					result = append(result, v.Time.Sub(start))
+				}
+				done <- result
+			}()
+
+			scheduler.AdvanceBy(3 * time.Hour)
+			require.Equal(t, []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}, <-done)
+		})
+	})
+
+	t.Run("DebounceTime", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			scheduler := rx.NewTestScheduler(synctest.Wait)
+			subject := rx.NewSubject[int]()
+
+			done := make(chan []rx.TimeInterval[int])
+			go func() {
+				result := make([]rx.TimeInterval[int], 0)
+				for v, err := range rx.Pipe2(
+					subject,
+					rx.DebounceTime[int](time.Minute, scheduler),
+					rx.WithTimeInterval[int](scheduler),
+				).Subscribe() {
+					require.NoError(t, err)
+					result = append(result, v)
+				}
+				done <- result
+			}()
+			for !subject.Observed() {
+				time.Sleep(time.Millisecond)
+			}
+
+			subject.Next(1)
+			scheduler.AdvanceBy(30 * time.Second)
+			subject.Next(2)
+			scheduler.AdvanceBy(time.Minute)
+			subject.Next(3)
+			scheduler.AdvanceBy(10 * time.Second)
+			subject.Complete()
+
+			require.Equal(t, []rx.TimeInterval[int]{
+				{Interval: 90 * time.Second, Value: 2},
+				{Interval: 100 * time.Second, Value: 3},
+			}, <-done)
+		})
+	})
+}
+
+func TestMarble(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	values := map[string]int{"a": 1, "b": 2, "c": 3}
+
+	t.Run("Map", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold(s, "-a-b-(c|)", values, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.Map(func(v int, _ int) int {
+				return v * 10
+			}))).ToBe("-a-b-(c|)", map[string]int{"a": 10, "b": 20, "c": 30}, nil)
+			s.ExpectSubscriptions(source).ToBe("^----!")
+		})
+	})
+
+	t.Run("DebounceTime", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold(s, "-a--b---c|", values, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.DebounceTime[int](2*rxtest.Frame))).
+				ToBe("---a--b--(c|)", values, nil)
+			s.ExpectSubscriptions(source).ToBe("^--------!")
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("boom")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold(s, "-a-#", values, err)
+			rxtest.ExpectObservable(s, source).ToBe("-a-#", values, err)
+		})
+	})
+
+	t.Run("Hot and unsubscription", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Hot(s, "-a-^-b-c-|", values, nil)
+			rxtest.ExpectObservable(s, source, "^----!").ToBe("--b-c", values, nil)
+			s.ExpectSubscriptions(source).ToBe("^----!")
+		})
+	})
+
+	t.Run("Never", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.TakeUntil[string](rx.Timer[int](3*rxtest.Frame)))).
+				ToBe("-a-|", nil, nil)
+			s.ExpectSubscriptions(source).ToBe("^--!")
+		})
+	})
+}
+
+func TestDistinct(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe1(
+		rx.From[int]([]int{1, 1, 2, 2, 2, 1, 2, 3, 4, 3, 2, 1}),
+		rx.Distinct(func(v int) int {
+			return v
+		}),
+	), []int{1, 2, 3, 4})
+
+	type user struct {
+		name string
+		age  int
+	}
+
+	assertItem(t, rx.Pipe1(
+		rx.From[user]([]user{
+			{"Foo", 4},
+			{"Bar", 7},
+			{"Foo", 5},
+		}),
+		rx.Distinct(func(v user) string {
+			return v.name
+		}),
+	), []user{{"Foo", 4}, {"Bar", 7}})
+}
+
+func TestDistinctUntilChanged(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe1(
+		rx.Of(1, 1, 1, 2, 2, 2, 1, 1, 3, 3),
+		rx.DistinctUntilChanged[int](),
+	), []int{1, 2, 1, 3})
+}
+
+func TestElementAt(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Valid index", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Of(1, 2, 3, 4, 5),
+			rx.ElementAt[int](2),
+		), []int{3})
+	})
+
+	t.Run("Invalid index", func(t *testing.T) {
+		isError(t, rx.Pipe1(
+			rx.Of(1, 2, 3, 4, 5),
+			rx.ElementAt[int](10),
+		), rx.ErrArgumentOutOfRange)
+	})
+}
+
+func TestEvery(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("true", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Of(1, 1, 1, 1, 1, 3, 3),
+			rx.Every(func(v int, _ int) bool {
+				return v%2 != 0
+			}),
+		), []bool{true})
+	})
+
+	t.Run("false", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Of(1, 1, 1, 1, 1, 3, 3),
+			rx.Every(func(v int, _ int) bool {
+				return v%2 == 0
+			}),
+		), []bool{false})
+	})
+}
+
+func TestFirst(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe1(
+		rx.From[string]([]string{"Ben", "Tracy", "Laney", "Lily"}),
+		rx.First[string](),
+	), []string{"Ben"})
+}
+
+func TestFilter(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItem(t, rx.Pipe1(
+		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
+		rx.Filter(func(v int) bool {
+			return v%2 == 0
+		}),
+	), []int{2, 4, 8, 10, 14})
+}
+
+func TestForkJoin(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	assertItems(t, rx.ForkJoin(
+		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
+		rx.Timer[int](time.Second),
+	), [][]int{{14, 0}})
+
+	assertItem(t, rx.ForkJoin(
+		rx.Empty[int](),
+		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
+		rx.Timer[int](time.Second),
+	), [][]int{})
+}
+
+func TestIsEmpty(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Empty", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Empty[any](),
+			rx.IsEmpty[any](),
+		), []bool{true})
+	})
+
+	t.Run("Not empty", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Of(1),
+			rx.IsEmpty[int](),
+		), []bool{false})
+	})
+}
+
+type resource struct {
+	closes int
+	err    error
+}
+
+func (r *resource) Close() error {
+	r.closes++
+	return r.err
+}
+
+func TestUsing(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	err := errors.New("failed")
+	using := func(r *resource, source rx.Observable[int]) rx.Observable[int] {
+		return rx.Using(func() (*resource, error) {
+			return r, nil
+		}, func(*resource) rx.Observable[int] {
+			return source
+		})
+	}
+
+	t.Run("Complete", func(t *testing.T) {
+		r := &resource{}
+		assertItem(t, using(r, rx.Of(1, 2)), []int{1, 2})
+		require.Equal(t, 1, r.closes)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		r := &resource{}
+		isError(t, using(r, rx.ThrowError[int](func() error { return err })), err)
+		require.Equal(t, 1, r.closes)
+	})
+
+	t.Run("Early stop", func(t *testing.T) {
+		r := &resource{}
+		for range using(r, rx.Of(1, 2, 3)).Subscribe() {
+			break
+		}
+		require.Equal(t, 1, r.closes)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		r := &resource{}
+		require.Panics(t, func() {
+			for range using(r, rx.Of(1, 2)).Subscribe() {
+				panic("boom")
+			}
+		})
+		require.Equal(t, 1, r.closes)
+	})
+
+	t.Run("Close error", func(t *testing.T) {
+		r := &resource{err: err}
+		isError(t, using(r, rx.Of(1)), err)
+		require.Equal(t, 1, r.closes)
+	})
+
+	t.Run("Factory error", func(t *testing.T) {
+		var subscribed bool
+		isError(t, rx.Using(func() (*resource, error) {
+			return nil, err
+		}, func(*resource) rx.Observable[int] {
+			subscribed = true
+			return rx.Of(1)
+		}), err)
+		require.False(t, subscribed)
+	})
+
+	t.Run("Per subscription", func(t *testing.T) {
+		var opened []*resource
+		obs := rx.Using(func() (*resource, error) {
+			r := &resource{}
+			opened = append(opened, r)
+			return r, nil
+		}, func(*resource) rx.Observable[int] {
+			return rx.Of(1)
+		})
+		assertItem(t, obs, []int{1})
+		assertItem(t, obs, []int{1})
+		require.Len(t, opened, 2)
+		for _, r := range opened {
+			require.Equal(t, 1, r.closes)
+		}
+	})
+}
+
+func TestRange(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Negative range values", func(t *testing.T) {
+		assertItem(t, rx.Range(-5, 1), []int{-5, -4, -3, -2, -1, 0, 1})
+	})
+	t.Run("Positive range values", func(t *testing.T) {
+		assertItem(t, rx.Range(1, 5), []int{1, 2, 3, 4, 5})
+	})
+}
+
+func TestReduce(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Negative values", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.Of(-88, 1, 2, -3, 888),
+			rx.Reduce(func(acc int, v int, _ int) int {
+				return acc + v
+			}, int(0)),
+		), []int{800})
+	})
+
+	t.Run("Positive values", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(
+			rx.From[uint]([]uint{100, 1, 2, 888}),
+			rx.Reduce(func(acc uint, v uint, _ int) uint {
+				return acc + v
+			}, uint(0)),
+		), []uint{991})
+	})
+}
+
+func TestCompose(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	increment := rx.Map(func(v int, _ int) int { return v + 1 })
+
+	t.Run("Compose", func(t *testing.T) {
+		ops := make([]rx.OperatorFunc[int, int], 12)
+		for i := range ops {
+			ops[i] = increment
+		}
+		assertItem(t, rx.Pipe1(rx.Of(0, 10), rx.Compose(ops...)), []int{12, 22})
+		assertItem(t, rx.Pipe1(rx.Of(1), rx.Compose[int]()), []int{1})
+	})
+
+	t.Run("Chain", func(t *testing.T) {
+		chain := rx.Then(
+			rx.NewChain(rx.Of(1, 2, 3)).Pipe(
+				increment,
+				rx.Filter(func(v int) bool { return v > 2 }),
+			),
+			rx.Map(func(v int, _ int) string { return strings.Repeat("*", v) }),
+		).Pipe(rx.Take[string](1))
+		assertItem(t, chain, []string{"***"})
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		err := errors.New("failed")
+		parse := rx.Named("parse", rx.MapErr(func(v int, _ int) (int, error) {
+			if v > 1 {
+				return 0, err
+			}
+			return v, nil
+		}))
+
+		var stageErr *rx.StageError
+		for _, e := range rx.NewChain(rx.Of(1, 2)).Pipe(rx.Named("increment", increment), parse).Subscribe() {
+			if e != nil {
+				require.ErrorIs(t, e, err)
+				require.ErrorAs(t, e, &stageErr)
+				require.Equal(t, "parse", stageErr.Stage)
+				require.EqualError(t, e, `rxgo: stage "parse": failed`)
+			}
+		}
+		require.NotNil(t, stageErr)
+	})
+
+	t.Run("Upstream error", func(t *testing.T) {
+		err := errors.New("failed")
+		for _, e := range rx.Pipe1(rx.ThrowError[int](func() error { return err }), rx.Named("increment", increment)).Subscribe() {
+			require.Same(t, err, e)
+		}
+	})
+
+	t.Run("Nested stages", func(t *testing.T) {
+		err := errors.New("failed")
+		isError(t, rx.Pipe1(rx.Of(1), rx.Named("outer", rx.Compose(
+			increment,
+			rx.Named("inner", rx.MapErr(func(int, int) (int, error) { return 0, err })),
+		))), err)
+		for _, e := range rx.Pipe1(rx.Of(1), rx.Named("outer", rx.Named("inner", rx.MapErr(func(int, int) (int, error) { return 0, err })))).Subscribe() {
+			require.EqualError(t, e, `rxgo: stage "outer": rxgo: stage "inner": failed`)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		err := errors.New("failed")
+		explode := rx.Named("explode", rx.Map(func(v int, _ int) int {
+			if v > 1 {
+				panic(err)
+			}
+			return v
+		}))
+		require.PanicsWithError(t, `rxgo: stage "explode" panicked: failed`, func() {
+			for range rx.Pipe1(rx.Of(1, 2), explode).Subscribe() {
+			}
+		})
+		func() {
+			defer func() {
+				r := recover()
+				stagePanic, ok := r.(*rx.StagePanic)
+				require.True(t, ok)
+				require.Equal(t, "explode", stagePanic.Stage)
+				require.ErrorIs(t, stagePanic, err)
+			}()
+			for range rx.Pipe2(rx.Of(1, 2), increment, explode).Subscribe() {
+			}
+		}()
+
+		// The panics of the previous stages and of the consumer go through as is.
+		require.PanicsWithValue(t, "upstream", func() {
+			for range rx.Pipe2(rx.Of(1), rx.Map(func(int, int) int { panic("upstream") }), rx.Named("increment", increment)).Subscribe() {
+			}
+		})
+		require.PanicsWithValue(t, "downstream", func() {
+			for range rx.Pipe1(rx.Of(1), rx.Named("increment", increment)).Subscribe() {
+				panic("downstream")
+			}
+		})
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, e := range rx.Pipe1(rx.Interval(time.Millisecond), rx.Named("ticks", rx.Take[int](10))).SubscribeContext(ctx) {
+			if e != nil {
+				require.Same(t, context.Canceled, e)
+				break
+			}
+			cancel()
+		}
+	})
+}
+
+func TestMergeTyped(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("Merge2", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			orders := rxtest.Cold(s, "-a--b|", map[string]string{"a": "order-1", "b": "order-2"}, nil)
+			heartbeats := rxtest.Cold(s, "--x--y|", map[string]int{"x": 1, "y": 2}, nil)
+			rxtest.ExpectObservable(s, rx.Merge2(orders, heartbeats)).ToBe("-ax-by|", map[string]rx.Either[string, int]{
+				"a": rx.NewA[int]("order-1"),
+				"b": rx.NewA[int]("order-2"),
+				"x": rx.NewB[string](1),
+				"y": rx.NewB[string](2),
+			}, nil)
+		})
+	})
+
+	t.Run("Same types", func(t *testing.T) {
+		var left, right []int
+		for v, err := range rx.Merge2(rx.Of(1, 2), rx.Of(3)).Subscribe() {
+			require.NoError(t, err)
+			if v.IsA() {
+				left = append(left, v.MustA())
+			} else {
+				right = append(right, v.MustB())
+			}
+		}
+		require.Equal(t, []int{1, 2}, left)
+		require.Equal(t, []int{3}, right)
+
+		e := rx.NewB[int](7)
+		_, ok := e.A()
+		require.False(t, ok)
+		v, ok := e.B()
+		require.True(t, ok)
+		require.Equal(t, 7, v)
+		require.Panics(t, func() { e.MustA() })
+	})
+
+	t.Run("Fold", func(t *testing.T) {
+		describe := func(e rx.Either[string, int]) string {
+			return rx.Fold(e, func(s string) string { return "order " + s }, func(n int) string { return fmt.Sprintf("heartbeat %d", n) })
+		}
+		require.Equal(t, "order a", describe(rx.NewA[int]("a")))
+		require.Equal(t, "heartbeat 1", describe(rx.NewB[string](1)))
+
+		var matched string
+		rx.NewB[string](2).Match(func(s string) { matched = s }, func(n int) { matched = fmt.Sprint(n) })
+		require.Equal(t, "2", matched)
+	})
+
+	t.Run("Merge3", func(t *testing.T) {
+		var result []string
+		for v, err := range rx.Merge3(rx.Of("a"), rx.Of(1), rx.Of(true)).Subscribe() {
+			require.NoError(t, err)
+			result = append(result, rx.FoldOneOf3(v,
+				func(s string) string { return "string " + s },
+				func(n int) string { return fmt.Sprintf("int %d", n) },
+				func(b bool) string { return fmt.Sprintf("bool %t", b) },
+			))
+		}
+		slices.Sort(result)
+		require.Equal(t, []string{"bool true", "int 1", "string a"}, result)
+
+		o := rx.NewOneOf3C[int, int](3)
+		require.Equal(t, 2, o.Index())
+		_, ok := o.B()
+		require.False(t, ok)
+		var matched int
+		o.Match(func(int) { matched = 1 }, func(int) { matched = 2 }, func(v int) { matched = v * 10 })
+		require.Equal(t, 30, matched)
+	})
+}
+
+func TestMergeMap(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	values := map[string]string{"p": "ax", "q": "ay", "r": "bx", "s": "by"}
+
+	t.Run("Concurrent", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a--b-----|", nil, nil)
+			inner := rxtest.Cold[string](s, "--x-y|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.MergeMap(func(v string, _ int) rx.Observable[string] {
+				return rx.Pipe1(inner, rx.Map(func(x string, _ int) string { return v + x }))
+			}))).ToBe("--p-qr-s-|", values, nil)
+			s.ExpectSubscriptions(inner).ToBe("^----!", "---^----!")
+		})
+	})
+
+	t.Run("Max concurrency", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a--b-----|", nil, nil)
+			inner := rxtest.Cold[string](s, "--x-y|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.MergeMap(func(v string, _ int) rx.Observable[string] {
+				return rx.Pipe1(inner, rx.Map(func(x string, _ int) string { return v + x }))
+			}, 1))).ToBe("--p-q--r-s|", values, nil)
+			s.ExpectSubscriptions(inner).ToBe("^----!", "-----^----!")
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a-b|", nil, nil)
+			slow := rxtest.Cold[string](s, "-x-----y|", nil, nil)
+			failing := rxtest.Cold[string](s, "---#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.MergeMap(func(v string, _ int) rx.Observable[string] {
+				if v == "b" {
+					return failing
+				}
+				return slow
+			}))).ToBe("-x---#", nil, err)
+			s.ExpectSubscriptions(slow).ToBe("^----!")
+		})
+	})
+
+	t.Run("Type changing", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(rx.Of(1, 2, 3), rx.MergeMap(func(v int, _ int) rx.Observable[string] {
+			return rx.Of(strings.Repeat("*", v))
+		}, 1)), []string{"*", "**", "***"})
+	})
+}
+
+func TestParallelMap(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	assertItem(t, rx.Pipe3(
-		rx.Range(1, 20),
-		rx.Filter(func(v int) bool {
-			return v%2 == 0
-		}),
-		rx.MapErr(func(v int, _ int) (int, error) {
-			if v > 10 {
-				return 0, errors.New(`stop la`)
+	square := func(v int, _ int) int {
+		// The first values are the slowest, so the results are ready out of order.
+		time.Sleep(time.Duration(21-v) * 100 * time.Microsecond)
+		return v * v
+	}
+
+	t.Run("Ordered", func(t *testing.T) {
+		expected := make([]int, 0, 20)
+		for i := 1; i <= 20; i++ {
+			expected = append(expected, i*i)
+		}
+		assertItem(t, rx.Pipe1(rx.Range(1, 20), rx.ParallelMap(4, square)), expected)
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		var result []int
+		for v, err := range rx.Pipe1(rx.Range(1, 20), rx.ParallelMap(4, square, rx.ParallelOptions{Unordered: true})).Subscribe() {
+			require.NoError(t, err)
+			result = append(result, v)
+		}
+		require.Len(t, result, 20)
+		slices.Sort(result)
+		for i, v := range result {
+			require.Equal(t, (i+1)*(i+1), v)
+		}
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+		var running, maxRunning, emitted, maxInFlight atomic.Int64
+		obs := rx.Pipe2(
+			rx.Range(1, 50),
+			rx.Tap(func(int) {
+				maxInFlight.Store(max(maxInFlight.Load(), emitted.Add(1)))
+			}),
+			rx.ParallelMap(3, func(v int, _ int) int {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return v
+			}, rx.ParallelOptions{MaxInFlight: 5}),
+		)
+		var count int
+		for _, err := range obs.Subscribe() {
+			require.NoError(t, err)
+			emitted.Add(-1)
+			count++
+		}
+		require.Equal(t, 50, count)
+		require.LessOrEqual(t, maxRunning.Load(), int64(3))
+		// The source may emit one value ahead, while waiting for a free slot.
+		require.LessOrEqual(t, maxInFlight.Load(), int64(6))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		var calls atomic.Int64
+		isError(t, rx.Pipe1(rx.Range(1, 1000), rx.ParallelMapErr(4, func(v int, _ int) (int, error) {
+			calls.Add(1)
+			if v == 5 {
+				return 0, err
 			}
 			return v, nil
-		}),
-		rx.CatchError2[int](func(err error) rx.Observable[string] {
-			return rx.From[string]([]string{"I", "II", "III", "IV", "V"})
-		}),
-	), []rx.Either[int, string]{
-		rx.NewA[string](2),
-		rx.NewA[string](4),
-		rx.NewA[string](6),
-		rx.NewA[string](8),
-		rx.NewA[string](10),
-		rx.NewB[int]("I"),
-		rx.NewB[int]("II"),
-		rx.NewB[int]("III"),
-		rx.NewB[int]("IV"),
-		rx.NewB[int]("V"),
+		})), err)
+		require.Less(t, calls.Load(), int64(1000))
+	})
+
+	t.Run("Source error", func(t *testing.T) {
+		err := errors.New("failed")
+		isError(t, rx.Pipe1(rx.Concat(rx.Of(1, 2), rx.ThrowError[int](func() error { return err })), rx.ParallelMap(2, square)), err)
+
+		// The values emitted before the error of the source are never lost.
+		for _, opt := range []rx.ParallelOptions{{}, {Unordered: true}} {
+			var result []int
+			for v, e := range rx.Pipe1(rx.Concat(rx.Of(1, 2, 3), rx.ThrowError[int](func() error { return err })), rx.ParallelMap(2, square, opt)).Subscribe() {
+				if e != nil {
+					require.ErrorIs(t, e, err)
+					break
+				}
+				result = append(result, v)
+			}
+			require.ElementsMatch(t, []int{1, 4, 9}, result)
+		}
 	})
 }
 
-func TestDefaultIfEmpty(t *testing.T) {
+func TestSwitchMap(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	assertItem(t, rx.Pipe1(
-		rx.Empty[string](),
-		rx.DefaultIfEmpty(`hello world!`),
-	), []string{`hello world!`})
+	values := map[string]string{"p": "ax", "q": "ay", "r": "bx", "s": "by", "t": "cx", "u": "cy"}
+
+	t.Run("SwitchMap", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a--b------|", nil, nil)
+			inner := rxtest.Cold[string](s, "--x-y|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.SwitchMap(func(v string, _ int) rx.Observable[string] {
+				return rx.Pipe1(inner, rx.Map(func(x string, _ int) string { return v + x }))
+			}))).ToBe("--p--r-s--|", values, nil)
+			s.ExpectSubscriptions(inner).ToBe("^--!", "---^----!")
+		})
+	})
+
+	t.Run("ExhaustMap", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a--b--c---|", nil, nil)
+			inner := rxtest.Cold[string](s, "--x-y|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.ExhaustMap(func(v string, _ int) rx.Observable[string] {
+				return rx.Pipe1(inner, rx.Map(func(x string, _ int) string { return v + x }))
+			}))).ToBe("--p-q---t-u|", values, nil)
+			s.ExpectSubscriptions(inner).ToBe("^----!", "------^----!")
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a-b---|", nil, nil)
+			inner := rxtest.Cold[string](s, "-x-#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe1(source, rx.SwitchMap(func(v string, _ int) rx.Observable[string] {
+				return inner
+			}))).ToBe("-x-x-#", nil, err)
+		})
+	})
 }
 
-func TestDistinct(t *testing.T) {
+func TestGroupBy(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	assertItem(t, rx.Pipe1(
-		rx.From[int]([]int{1, 1, 2, 2, 2, 1, 2, 3, 4, 3, 2, 1}),
-		rx.Distinct(func(v int) int {
-			return v
-		}),
-	), []int{1, 2, 3, 4})
+	collect := func(t *testing.T, groups rx.Observable[rx.GroupedObservable[int, int]]) map[int][]int {
+		result := make(map[int][]int)
+		for v, err := range rx.Pipe1(groups, rx.MergeMap(func(g rx.GroupedObservable[int, int], _ int) rx.Observable[rx.Tuple[int, []int]] {
+			return rx.Pipe1(g, rx.Map(func(v int, _ int) rx.Tuple[int, []int] {
+				return rx.NewTuple(g.Key(), []int{v})
+			}))
+		})).Subscribe() {
+			require.NoError(t, err)
+			result[v.Left] = append(result[v.Left], v.Right...)
+		}
+		return result
+	}
 
-	type user struct {
-		name string
-		age  int
+	t.Run("GroupBy", func(t *testing.T) {
+		require.Equal(t, map[int][]int{
+			0: {3, 6, 9},
+			1: {1, 4, 7, 10},
+			2: {2, 5, 8},
+		}, collect(t, rx.Pipe1(rx.Range(1, 10), rx.GroupBy(func(v int) int { return v % 3 }))))
+	})
+
+	t.Run("GroupByElement", func(t *testing.T) {
+		result := make(map[int][]string)
+		for v, err := range rx.Pipe2(
+			rx.Range(1, 4),
+			rx.GroupByElement(func(v int) int { return v % 2 }, strconv.Itoa),
+			rx.MergeMap(func(g rx.GroupedObservable[int, string], _ int) rx.Observable[rx.Tuple[int, []string]] {
+				return rx.Pipe2(g, rx.ToSlice[string](), rx.Map(func(v []string, _ int) rx.Tuple[int, []string] {
+					return rx.NewTuple(g.Key(), v)
+				}))
+			}),
+		).Subscribe() {
+			require.NoError(t, err)
+			result[v.Left] = v.Right
+		}
+		require.Equal(t, map[int][]string{0: {"2", "4"}, 1: {"1", "3"}}, result)
+	})
+
+	// The groups are collected before being subscribed, once the source has completed.
+	groupsOf := func(t *testing.T, opt rx.GroupByOptions[int, int]) []rx.GroupedObservable[int, int] {
+		groups, err := rx.LastValueFrom(context.Background(), rx.Pipe2(
+			rx.Range(0, 20),
+			rx.GroupBy(func(v int) int { return v % 2 }, opt),
+			rx.ToSlice[rx.GroupedObservable[int, int]](),
+		))
+		require.NoError(t, err)
+		return groups
 	}
 
-	assertItem(t, rx.Pipe1(
-		rx.From[user]([]user{
-			{"Foo", 4},
-			{"Bar", 7},
-			{"Foo", 5},
-		}),
-		rx.Distinct(func(v user) string {
-			return v.name
-		}),
-	), []user{{"Foo", 4}, {"Bar", 7}})
-}
+	t.Run("BufferSize with OverflowDropNewest", func(t *testing.T) {
+		groups := groupsOf(t, rx.GroupByOptions[int, int]{BufferSize: 2, Overflow: rx.OverflowDropNewest})
+		require.Len(t, groups, 2)
+		assertItem[int](t, groups[0], []int{0, 2})
+		assertItem[int](t, groups[1], []int{1, 3})
+	})
 
-func TestDistinctUntilChanged(t *testing.T) {
-	defer goleak.VerifyNone(t)
+	t.Run("BufferSize with OverflowDropOldest", func(t *testing.T) {
+		groups := groupsOf(t, rx.GroupByOptions[int, int]{BufferSize: 2, Overflow: rx.OverflowDropOldest})
+		require.Len(t, groups, 2)
+		assertItem[int](t, groups[0], []int{18, 20})
+		assertItem[int](t, groups[1], []int{17, 19})
+	})
 
-	assertItem(t, rx.Pipe1(
-		rx.Of(1, 1, 1, 2, 2, 2, 1, 1, 3, 3),
-		rx.DistinctUntilChanged[int](),
-	), []int{1, 2, 1, 3})
-}
+	t.Run("BufferSize with OverflowError", func(t *testing.T) {
+		groups := groupsOf(t, rx.GroupByOptions[int, int]{BufferSize: 4})
+		require.Len(t, groups, 6)
+		for i, expected := range [][]int{{0, 2, 4, 6}, {1, 3, 5, 7}, {8, 10, 12, 14}, {9, 11, 13, 15}} {
+			var values []int
+			for v, err := range groups[i].Subscribe() {
+				if err != nil {
+					require.ErrorIs(t, err, rx.ErrBufferOverflow)
+					break
+				}
+				values = append(values, v)
+			}
+			require.Equal(t, expected, values)
+		}
+		assertItem[int](t, groups[4], []int{16, 18, 20})
+		assertItem[int](t, groups[5], []int{17, 19})
+	})
 
-func TestElementAt(t *testing.T) {
-	defer goleak.VerifyNone(t)
+	t.Run("BufferSize with an unsubscribed group", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[int](s, "-a-b-c-d-e-f-g-h|", map[string]int{
+				"a": 0, "b": 1, "c": 2, "d": 3, "e": 4, "f": 5, "g": 6, "h": 7,
+			}, nil)
+			rxtest.ExpectObservable(s, rx.Pipe3(
+				source,
+				rx.GroupBy(func(v int) int { return v % 2 }, rx.GroupByOptions[int, int]{BufferSize: 1}),
+				rx.Filter(func(g rx.GroupedObservable[int, int]) bool { return g.Key() == 0 }),
+				rx.MergeMap(func(g rx.GroupedObservable[int, int], _ int) rx.Observable[int] {
+					return g
+				}),
+			)).ToBe("-a---c---e---g--|", map[string]int{"a": 0, "c": 2, "e": 4, "g": 6}, nil)
+		})
+	})
 
-	t.Run("Valid index", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Of(1, 2, 3, 4, 5),
-			rx.ElementAt[int](2),
-		), []int{3})
+	t.Run("BufferSize with a Duration ignoring its group", func(t *testing.T) {
+		// The Duration is a timer, the values buffered for it are never read.
+		groupBy := func(source rx.Observable[string], overflow rx.OverflowPolicy) rx.Observable[string] {
+			return rx.Pipe2(
+				source,
+				rx.GroupBy(func(string) string { return "" }, rx.GroupByOptions[string, string]{
+					Duration: func(rx.GroupedObservable[string, string]) rx.Observable[string] {
+						return rx.Pipe1(rx.Timer[int](time.Hour), rx.Map(func(int, int) string { return "" }))
+					},
+					BufferSize: 2,
+					Overflow:   overflow,
+				}),
+				rx.MergeMap(func(g rx.GroupedObservable[string, string], _ int) rx.Observable[string] {
+					return g
+				}),
+			)
+		}
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d|", nil, nil)
+			rxtest.ExpectObservable(s, groupBy(source, rx.OverflowError)).ToBe("-a-b-(c#)", nil, rx.ErrBufferOverflow)
+		})
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d|", nil, nil)
+			rxtest.ExpectObservable(s, groupBy(source, rx.OverflowDropNewest)).ToBe("-a-b-c-d|", nil, nil)
+		})
 	})
 
-	t.Run("Invalid index", func(t *testing.T) {
-		isError(t, rx.Pipe1(
-			rx.Of(1, 2, 3, 4, 5),
-			rx.ElementAt[int](10),
-		), rx.ErrArgumentOutOfRange)
+	t.Run("Duration", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "a-a----a|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(
+				source,
+				rx.GroupBy(func(v string) string { return v }, rx.GroupByOptions[string, string]{
+					Duration: func(group rx.GroupedObservable[string, string]) rx.Observable[string] {
+						return rx.Pipe1(group, rx.DebounceTime[string](3*rxtest.Frame))
+					},
+				}),
+				rx.MergeMap(func(g rx.GroupedObservable[string, string], _ int) rx.Observable[string] {
+					return rx.Pipe2(g, rx.ToSlice[string](), rx.Map(func(v []string, _ int) string {
+						return strings.Join(v, "")
+					}))
+				}),
+			)).ToBe("-----x--(y|)", map[string]string{"x": "aa", "y": "a"}, nil)
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		isError(t, rx.Pipe2(
+			rx.Concat(rx.Range(1, 5), rx.ThrowError[int](func() error { return err })),
+			rx.GroupBy(func(v int) int { return v % 2 }),
+			rx.MergeMap(func(g rx.GroupedObservable[int, int], _ int) rx.Observable[[]int] {
+				return rx.Pipe1(g, rx.ToSlice[int]())
+			}),
+		), err)
 	})
 }
 
-func TestEvery(t *testing.T) {
+func TestWindow(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	t.Run("true", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Of(1, 1, 1, 1, 1, 3, 3),
-			rx.Every(func(v int, _ int) bool {
-				return v%2 != 0
-			}),
-		), []bool{true})
+	join := func(w rx.Observable[string], _ int) rx.Observable[string] {
+		return rx.Pipe2(w, rx.ToSlice[string](), rx.Map(func(v []string, _ int) string {
+			return strings.Join(v, "")
+		}))
+	}
+
+	t.Run("Window", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			boundaries := rxtest.Cold[string](s, "--x---x|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.Window[string](boundaries), rx.ConcatMap(join))).
+				ToBe("--x---y---(z|)", map[string]string{"x": "a", "y": "bc", "z": "de"}, nil)
+		})
 	})
 
-	t.Run("false", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Of(1, 1, 1, 1, 1, 3, 3),
-			rx.Every(func(v int, _ int) bool {
-				return v%2 == 0
+	t.Run("WindowCount", func(t *testing.T) {
+		windows := rx.Pipe2(rx.Range(1, 5), rx.WindowCount[int](2), rx.ConcatMap(func(w rx.Observable[int], _ int) rx.Observable[[]int] {
+			return rx.Pipe1(w, rx.ToSlice[int]())
+		}))
+		assertItems(t, windows, [][]int{{1, 2}, {3, 4}, {5}})
+	})
+
+	t.Run("WindowCount with startWindowEvery", func(t *testing.T) {
+		windows := rx.Pipe2(rx.Range(1, 5), rx.WindowCount[int](3, 2), rx.MergeMap(func(w rx.Observable[int], _ int) rx.Observable[[]int] {
+			return rx.Pipe1(w, rx.ToSlice[int]())
+		}, 1))
+		assertItems(t, windows, [][]int{{1, 2, 3}, {3, 4, 5}, {5}})
+	})
+
+	t.Run("WindowCount without window size", func(t *testing.T) {
+		require.Panics(t, func() { rx.WindowCount[int](0) })
+		require.Panics(t, func() { rx.WindowCount[int](0, 2) })
+	})
+
+	t.Run("WindowTime", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.WindowTime[string](4*rxtest.Frame), rx.ConcatMap(join))).
+				ToBe("----x---y-(z|)", map[string]string{"x": "ab", "y": "cd", "z": "e"}, nil)
+		})
+	})
+
+	t.Run("WindowTime with MaxSize", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.WindowTime[string](5*rxtest.Frame, rx.WindowTimeOptions{MaxSize: 2}), rx.ConcatMap(join))).
+				ToBe("---x---y--(z|)", map[string]string{"x": "ab", "y": "cd", "z": "e"}, nil)
+		})
+	})
+
+	t.Run("WindowTime with CreationInterval", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.WindowTime[string](2*rxtest.Frame, rx.WindowTimeOptions{CreationInterval: 4 * rxtest.Frame}), rx.MergeMap(join))).
+				ToBe("--x---y---(z|)", map[string]string{"x": "a", "y": "c", "z": "e"}, nil)
+		})
+	})
+
+	t.Run("WindowToggle", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			openings := rxtest.Cold[string](s, "--o---o|", nil, nil)
+			closing := rxtest.Cold[string](s, "--x", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.WindowToggle[string](openings, func(string) rx.Observable[string] {
+				return closing
+			}), rx.MergeMap(join))).ToBe("----x---y-|", map[string]string{"x": "b", "y": "d"}, nil)
+			s.ExpectSubscriptions(closing).ToBe("--^-!", "------^-!")
+		})
+	})
+
+	t.Run("WindowWhen", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-c-d-e|", nil, nil)
+			closing := rxtest.Cold[string](s, "----x", nil, nil)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.WindowWhen[string](func() rx.Observable[string] {
+				return closing
+			}), rx.ConcatMap(join))).ToBe("----x---y-(z|)", map[string]string{"x": "ab", "y": "cd", "z": "e"}, nil)
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		isError(t, rx.Pipe2(
+			rx.Concat(rx.Range(1, 5), rx.ThrowError[int](func() error { return err })),
+			rx.WindowCount[int](2),
+			rx.MergeMap(func(w rx.Observable[int], _ int) rx.Observable[[]int] {
+				return rx.Pipe1(w, rx.ToSlice[int]())
 			}),
-		), []bool{false})
+		), err)
 	})
 }
 
-func TestFirst(t *testing.T) {
+func TestOnBackpressure(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	assertItem(t, rx.Pipe1(
-		rx.From[string]([]string{"Ben", "Tracy", "Laney", "Lily"}),
-		rx.First[string](),
-	), []string{"Ben"})
-}
+	// gated emits 1, then waits for the gate to be opened before emitting 2, 3, 4 and 5.
+	gated := func(gate, produced chan struct{}) rx.Observable[int] {
+		return rx.ObservableFunc[int](func(yield func(int, error) bool) {
+			defer close(produced)
+			if !yield(1, nil) {
+				return
+			}
+			<-gate
+			for i := 2; i <= 5; i++ {
+				if !yield(i, nil) {
+					return
+				}
+			}
+		})
+	}
+	// consume receives the first value, then lets the source overflow before receiving the others.
+	consume := func(t *testing.T, observable func(source rx.Observable[int]) rx.Observable[int]) ([]int, error) {
+		gate, produced := make(chan struct{}), make(chan struct{})
+		next, stop := iter.Pull2(observable(gated(gate, produced)).Subscribe())
+		defer stop()
 
-func TestFilter(t *testing.T) {
-	defer goleak.VerifyNone(t)
+		v, err, ok := next()
+		require.True(t, ok)
+		require.NoError(t, err)
+		result := []int{v}
+		close(gate)
+		<-produced
+		for {
+			v, err, ok := next()
+			if !ok {
+				return result, nil
+			} else if err != nil {
+				return result, err
+			}
+			result = append(result, v)
+		}
+	}
 
-	assertItem(t, rx.Pipe1(
-		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
-		rx.Filter(func(v int) bool {
-			return v%2 == 0
-		}),
-	), []int{2, 4, 8, 10, 14})
-}
+	t.Run("OnBackpressureBuffer", func(t *testing.T) {
+		var dropped []int
+		result, err := consume(t, func(source rx.Observable[int]) rx.Observable[int] {
+			return rx.Pipe1(source, rx.OnBackpressureBuffer(2, rx.OverflowDropOldest, func(v int, count uint64) {
+				dropped = append(dropped, v)
+				require.Equal(t, uint64(len(dropped)), count)
+			}))
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 4, 5}, result)
+		require.Equal(t, []int{2, 3}, dropped)
+	})
 
-func TestForkJoin(t *testing.T) {
-	defer goleak.VerifyNone(t)
+	t.Run("OnBackpressureBuffer with OverflowDropNewest", func(t *testing.T) {
+		var dropped []int
+		result, err := consume(t, func(source rx.Observable[int]) rx.Observable[int] {
+			return rx.Pipe1(source, rx.OnBackpressureBuffer(2, rx.OverflowDropNewest, func(v int, _ uint64) {
+				dropped = append(dropped, v)
+			}))
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, result)
+		require.Equal(t, []int{4, 5}, dropped)
+	})
 
-	assertItems(t, rx.ForkJoin(
-		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
-		rx.Timer[int](time.Second),
-	), [][]int{{14, 0}})
+	t.Run("OnBackpressureBuffer with OverflowError", func(t *testing.T) {
+		result, err := consume(t, func(source rx.Observable[int]) rx.Observable[int] {
+			return rx.Pipe1(source, rx.OnBackpressureBuffer[int](2, rx.OverflowError))
+		})
+		require.ErrorIs(t, err, rx.ErrBufferOverflow)
+		require.Equal(t, []int{1, 2, 3}, result)
+	})
 
-	assertItem(t, rx.ForkJoin(
-		rx.Empty[int](),
-		rx.Of(1, 2, 3, 4, 7, 8, 9, 10, 11, 14),
-		rx.Timer[int](time.Second),
-	), [][]int{})
+	t.Run("OnBackpressureLatest", func(t *testing.T) {
+		var count uint64
+		result, err := consume(t, func(source rx.Observable[int]) rx.Observable[int] {
+			return rx.Pipe1(source, rx.OnBackpressureLatest(func(_ int, dropped uint64) {
+				count = dropped
+			}))
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 5}, result)
+		require.Equal(t, uint64(3), count)
+	})
+
+	t.Run("OnBackpressureDrop", func(t *testing.T) {
+		produced := make(chan struct{})
+		source := rx.ObservableFunc[int](func(yield func(int, error) bool) {
+			defer close(produced)
+			for i := 1; i <= 5; i++ {
+				if !yield(i, nil) {
+					return
+				}
+			}
+		})
+
+		var (
+			result  []int
+			dropped atomic.Uint64
+		)
+		for v, err := range rx.Pipe1(source, rx.OnBackpressureDrop(func(_ int, count uint64) {
+			dropped.Store(count)
+		})).Subscribe() {
+			require.NoError(t, err)
+			result = append(result, v)
+			// Every value emitted while the consumer is busy is dropped.
+			<-produced
+		}
+		require.LessOrEqual(t, len(result), 1)
+		require.Equal(t, uint64(5-len(result)), dropped.Load())
+	})
 }
 
-func TestIsEmpty(t *testing.T) {
+func TestScheduler(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	t.Run("Empty", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Empty[any](),
-			rx.IsEmpty[any](),
-		), []bool{true})
+	expected := make([]int, 0, 100)
+	for i := range 100 {
+		expected = append(expected, i+1)
+	}
+
+	t.Run("ObserveOn", func(t *testing.T) {
+		pool := rx.NewWorkerPool(4)
+		defer pool.Close()
+
+		assertItem(t, rx.Pipe2(
+			rx.Range(1, 100),
+			rx.ObserveOn[int](pool, 8),
+			rx.Map(func(v int, _ int) int { return v }),
+		), expected)
+		assertItem(t, rx.Pipe1(rx.Range(1, 100), rx.ObserveOn[int](rx.GoroutineScheduler())), expected)
+		assertItem(t, rx.Pipe1(rx.Range(1, 100), rx.ObserveOn[int](rx.ImmediateScheduler())), expected)
 	})
 
-	t.Run("Not empty", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Of(1),
-			rx.IsEmpty[int](),
-		), []bool{false})
+	t.Run("SubscribeOnScheduler", func(t *testing.T) {
+		pool := rx.NewWorkerPool(1)
+		defer pool.Close()
+
+		assertItem(t, rx.Pipe1(rx.Range(1, 100), rx.SubscribeOnScheduler[int](pool, 8)), expected)
+		assertItem(t, rx.Pipe1(rx.Range(1, 100), rx.SubscribeOnScheduler[int](rx.GoroutineScheduler())), expected)
+		assertItem(t, rx.Pipe1(rx.Range(1, 100), rx.SubscribeOnScheduler[int](rx.ImmediateScheduler())), expected)
 	})
-}
 
-func TestRange(t *testing.T) {
-	defer goleak.VerifyNone(t)
+	t.Run("Error", func(t *testing.T) {
+		err := errors.New("failed")
+		source := rx.Concat(rx.Range(1, 3), rx.ThrowError[int](func() error { return err }))
+		isError(t, rx.Pipe1(source, rx.ObserveOn[int](rx.GoroutineScheduler())), err)
+		isError(t, rx.Pipe1(source, rx.SubscribeOnScheduler[int](rx.GoroutineScheduler())), err)
+	})
 
-	t.Run("Negative range values", func(t *testing.T) {
-		assertItem(t, rx.Range(-5, 1), []int{-5, -4, -3, -2, -1, 0, 1})
+	t.Run("Consumer panic", func(t *testing.T) {
+		pool := rx.NewWorkerPool(2)
+		defer pool.Close()
+
+		// The body of the loop runs on the Scheduler, its panic still reaches the caller.
+		for _, s := range []rx.Scheduler{rx.GoroutineScheduler(), pool, rx.ImmediateScheduler()} {
+			require.PanicsWithValue(t, "body", func() {
+				for v := range rx.Pipe1(rx.Of(1, 2, 3), rx.ObserveOn[int](s)).Subscribe() {
+					if v == 2 {
+						panic("body")
+					}
+				}
+			})
+		}
+
+		// So does runtime.Goexit, as called by t.FailNow.
+		var after atomic.Bool
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range rx.Pipe1(rx.Of(1, 2, 3), rx.ObserveOn[int](rx.GoroutineScheduler())).Subscribe() {
+				runtime.Goexit()
+			}
+			after.Store(true)
+		}()
+		<-done
+		require.False(t, after.Load())
 	})
-	t.Run("Positive range values", func(t *testing.T) {
-		assertItem(t, rx.Range(1, 5), []int{1, 2, 3, 4, 5})
+
+	t.Run("Early stop", func(t *testing.T) {
+		pool := rx.NewWorkerPool(2)
+		defer pool.Close()
+
+		assertItem(t, rx.Pipe2(rx.Interval(time.Millisecond), rx.ObserveOn[int](pool), rx.Take[int](3)), []int{0, 1, 2})
+		assertItem(t, rx.Pipe2(rx.Interval(time.Millisecond), rx.SubscribeOnScheduler[int](pool), rx.Take[int](3)), []int{0, 1, 2})
+	})
+
+	t.Run("TestScheduler", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			scheduler := rx.NewTestScheduler(synctest.Wait)
+
+			var (
+				mu     sync.Mutex
+				result []int
+				wg     sync.WaitGroup
+			)
+			wg.Go(func() {
+				for v, err := range rx.Pipe1(rx.Range(1, 5), rx.ObserveOn[int](scheduler)).Subscribe() {
+					require.NoError(t, err)
+					mu.Lock()
+					result = append(result, v)
+					mu.Unlock()
+				}
+			})
+
+			// Nothing is delivered until the virtual time moves forward.
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			require.Empty(t, result)
+			mu.Unlock()
+
+			scheduler.Flush()
+			wg.Wait()
+			require.Equal(t, []int{1, 2, 3, 4, 5}, result)
+		})
 	})
 }
 
-func TestReduce(t *testing.T) {
+func TestMaterialize(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
-	t.Run("Negative values", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.Of(-88, 1, 2, -3, 888),
-			rx.Reduce(func(acc int, v int, _ int) int {
-				return acc + v
-			}, int(0)),
-		), []int{800})
+	err := errors.New("failed")
+
+	t.Run("Materialize", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(rx.Of(1, 2), rx.Materialize[int]()), []rx.Notification[int]{
+			rx.NextNotification(1),
+			rx.NextNotification(2),
+			rx.CompleteNotification[int](),
+		})
+		assertItem(t, rx.Pipe1(rx.ThrowError[int](func() error { return err }), rx.Materialize[int]()), []rx.Notification[int]{
+			rx.ErrorNotification[int](err),
+		})
 	})
 
-	t.Run("Positive values", func(t *testing.T) {
-		assertItem(t, rx.Pipe1(
-			rx.From[uint]([]uint{100, 1, 2, 888}),
-			rx.Reduce(func(acc uint, v uint, _ int) uint {
-				return acc + v
-			}, uint(0)),
-		), []uint{991})
+	t.Run("Dematerialize", func(t *testing.T) {
+		assertItem(t, rx.Pipe1(rx.Of(
+			rx.NextNotification(1),
+			rx.CompleteNotification[int](),
+			rx.NextNotification(2),
+		), rx.Dematerialize[int]()), []int{1})
+		isError(t, rx.Pipe1(rx.Of(rx.NextNotification(1), rx.ErrorNotification[int](err)), rx.Dematerialize[int]()), err)
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		rxtest.Run(t, func(s *rxtest.Scheduler) {
+			source := rxtest.Cold[string](s, "-a-b-#", nil, err)
+			rxtest.ExpectObservable(s, rx.Pipe2(source, rx.Materialize[string](), rx.Dematerialize[string]())).ToBe("-a-b-#", nil, err)
+		})
+	})
+
+	t.Run("Errors as values", func(t *testing.T) {
+		// The error is filtered out, so the stream completes instead.
+		assertItem(t, rx.Pipe3(
+			rx.Concat(rx.Of(1, 2), rx.ThrowError[int](func() error { return err })),
+			rx.Materialize[int](),
+			rx.Filter(func(n rx.Notification[int]) bool { return n.Kind != rx.KindError }),
+			rx.Dematerialize[int](),
+		), []int{1, 2})
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		for _, n := range []rx.Notification[int]{
+			rx.NextNotification(1),
+			rx.ErrorNotification[int](err),
+			rx.CompleteNotification[int](),
+		} {
+			b, marshalErr := json.Marshal(n)
+			require.NoError(t, marshalErr)
+
+			var decoded rx.Notification[int]
+			require.NoError(t, json.Unmarshal(b, &decoded))
+			require.Equal(t, n.Kind, decoded.Kind)
+			require.Equal(t, n.Value, decoded.Value)
+			if n.Err != nil {
+				require.EqualError(t, decoded.Err, n.Err.Error())
+			}
+		}
+
+		b, marshalErr := json.Marshal(rx.NextNotification("a"))
+		require.NoError(t, marshalErr)
+		require.JSONEq(t, `{"kind":"next","value":"a"}`, string(b))
 	})
 }
 
@@ -343,6 +2539,16 @@ func assertItems[T any](t *testing.T, observable rx.Observable[[]T], expected []
 	require.ElementsMatch(t, result, expected)
 }
 
+func isErrorContext[T any](t *testing.T, ctx context.Context, observable rx.Observable[T], expectedErr error) {
+	for _, err := range observable.SubscribeContext(ctx) {
+		if err != nil {
+			require.ErrorIs(t, err, expectedErr)
+			return
+		}
+	}
+	require.Fail(t, "expected an error")
+}
+
 func isError[T any](t *testing.T, observable rx.Observable[T], expectedErr error) {
 	for _, err := range observable.Subscribe() {
 		if err != nil {
@@ -1,42 +1,65 @@
 package rx
 
 import (
+	"context"
 	"iter"
 	"reflect"
+	"sync"
 	"time"
 )
 
 // AuditTime ignores values from the source Observable for a duration, then emits the most recent value.
-func AuditTime[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func AuditTime[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
+
 			var (
 				latestValue T
-				stopped     bool
-				timer       *time.Timer
+				completed   bool
+				timer       ClockTimer
+				timeout     <-chan time.Time
 			)
 			defer func() {
 				if timer != nil {
 					timer.Stop()
 				}
 			}()
-			for v, err := range input.Subscribe() {
-				if err != nil {
-					var zero T
-					yield(zero, err)
-					return
-				} else if stopped {
+			for {
+				select {
+				case <-ctx.Done():
 					return
-				} else {
-					latestValue = v
-					if timer == nil {
-						timer = time.AfterFunc(duration, func() {
-							timer = nil
-							if !yield(latestValue, nil) {
-								stopped = true
-								return
-							}
-						})
+				case <-timeout:
+					timer, timeout = nil, nil
+					if !yield(latestValue, nil) {
+						return
+					}
+					if completed {
+						return
+					}
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						// The pending value is still emitted once the duration ends.
+						if timer == nil {
+							return
+						}
+						completed, ch = true, nil
+					} else {
+						latestValue = o.v
+						if timer == nil {
+							timer = clockOf(ctx, clock).NewTimer(duration)
+							timeout = timer.C()
+						}
 					}
 				}
 			}
@@ -45,35 +68,55 @@ func AuditTime[T any](duration time.Duration) OperatorFunc[T, T] {
 }
 
 // DebounceTime discards emitted values that take less than the specified time between output.
-func DebounceTime[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func DebounceTime[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			var latestValue T
-			var stopped bool
-			var timer *time.Timer
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
+
+			var (
+				latestValue T
+				timer       ClockTimer
+				timeout     <-chan time.Time
+			)
 			defer func() {
 				if timer != nil {
 					timer.Stop()
 				}
 			}()
-			for v, err := range input.Subscribe() {
-				if err != nil {
-					var zero T
-					yield(zero, err)
-					return
-				} else if stopped {
+			for {
+				select {
+				case <-ctx.Done():
 					return
-				} else {
-					if timer != nil {
-						timer.Stop()
+				case <-timeout:
+					timer, timeout = nil, nil
+					if !yield(latestValue, nil) {
+						return
 					}
-					timer = time.AfterFunc(duration, func() {
-						if !yield(latestValue, nil) {
-							stopped = true
-							return
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						// The pending value is emitted right away when the source completes.
+						if timer != nil {
+							yield(latestValue, nil)
 						}
-					})
-					latestValue = v
+						return
+					} else {
+						latestValue = o.v
+						if timer != nil {
+							timer.Stop()
+						}
+						timer = clockOf(ctx, clock).NewTimer(duration)
+						timeout = timer.C()
+					}
 				}
 			}
 		})
@@ -83,11 +126,11 @@ func DebounceTime[T any](duration time.Duration) OperatorFunc[T, T] {
 // Distinct suppresses duplicate items emitted by the source Observable.
 func Distinct[T any, K comparable](keySelector func(value T) K) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			keyCache := make(map[K]struct{})
 			defer clear(keyCache)
 			results := make([]T, 0)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -113,8 +156,8 @@ func Distinct[T any, K comparable](keySelector func(value T) K) OperatorFunc[T,
 // DistinctUntilChanged suppresses consecutive duplicate items emitted by the source Observable.
 func DistinctUntilChanged[T any](comparator ...func(prev, curr T) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			next, stop := iter.Pull2(input.SubscribeContext(ctx))
 			defer stop()
 
 			latestValue, err, ok := next()
@@ -162,9 +205,9 @@ func DistinctUntilChanged[T any](comparator ...func(prev, curr T) bool) Operator
 // ElementAt emits the single value at the specified index in a sequence of emissions from the source Observable.
 func ElementAt[T any](index int, defaultValue ...T) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -189,8 +232,8 @@ func ElementAt[T any](index int, defaultValue ...T) OperatorFunc[T, T] {
 // Filter emits only those items from an Observable that pass a predicate test.
 func Filter[T any](fn func(v T) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -209,8 +252,8 @@ func Filter[T any](fn func(v T) bool) OperatorFunc[T, T] {
 // FilterErr is similar to Filter but also stops if the predicate returns an error.
 func FilterErr[T any](fn func(v T) (bool, error)) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -233,8 +276,8 @@ func FilterErr[T any](fn func(v T) (bool, error)) OperatorFunc[T, T] {
 // First emits only the first item (or the first item that meets a condition) emitted by an Observable.
 func First[T any]() OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -253,8 +296,8 @@ func First[T any]() OperatorFunc[T, T] {
 // IgnoreElements ignores the values from the source Observable and only emits the completion or error signal.
 func IgnoreElements[T any]() OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			for _, err := range input.Subscribe() {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for _, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -268,9 +311,9 @@ func IgnoreElements[T any]() OperatorFunc[T, T] {
 // Last emits only the last item emitted by an Observable.
 func Last[T any]() OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var latestValue *T
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -288,20 +331,27 @@ func Last[T any]() OperatorFunc[T, T] {
 }
 
 // Emits the most recently emitted value from the source Observable within periodic time intervals.
-func SampleTime[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func SampleTime[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
 
-			timer := time.NewTicker(duration)
+			timer := clockOf(ctx, clock).NewTicker(duration)
 			defer timer.Stop()
 
 			var latestValue T
 			var emitted bool
 			for {
 				select {
-				case <-timer.C:
+				case <-ctx.Done():
+					return
+				case <-timer.C():
 					// sampleTime periodically looks at the source Observable and emits whichever value it has most recently emitted since the previous sampling, unless the source has not emitted anything since the previous sampling.
 					if emitted {
 						if !yield(latestValue, nil) {
@@ -309,15 +359,16 @@ func SampleTime[T any](duration time.Duration) OperatorFunc[T, T] {
 						}
 						emitted = false
 					}
-				default:
-					v, err, ok := next()
-					if err != nil {
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
 						return
-					} else if !ok {
+					} else if !o.ok {
 						return
 					} else {
 						emitted = true
-						latestValue = v
+						latestValue = o.v
 					}
 				}
 			}
@@ -328,10 +379,10 @@ func SampleTime[T any](duration time.Duration) OperatorFunc[T, T] {
 // Single emits a single item from the source Observable and then completes, or errors if the Observable is empty or emits more than one item.
 func Single[T any](predicate func(T, int) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var i int
 			var value *T
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -362,32 +413,45 @@ func Single[T any](predicate func(T, int) bool) OperatorFunc[T, T] {
 }
 
 // ThrottleTime emits a value from the source Observable, then ignores subsequent values for duration, then repeats this process.
-func ThrottleTime[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func ThrottleTime[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			var latestValue T
-			var emitted bool
-			var timer *time.Timer
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
+
+			var (
+				timer   ClockTimer
+				timeout <-chan time.Time
+			)
 			defer func() {
 				if timer != nil {
 					timer.Stop()
 				}
 			}()
-			for v, err := range input.Subscribe() {
-				if err != nil {
-					var zero T
-					yield(zero, err)
+			for {
+				select {
+				case <-ctx.Done():
 					return
-				} else {
-					latestValue = v
-					if !emitted {
-						if !yield(latestValue, nil) {
+				case <-timeout:
+					timer, timeout = nil, nil
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					} else if timer == nil {
+						if !yield(o.v, nil) {
 							return
 						}
-						emitted = true
-						timer = time.AfterFunc(duration, func() {
-							emitted = false
-						})
+						timer = clockOf(ctx, clock).NewTimer(duration)
+						timeout = timer.C()
 					}
 				}
 			}
@@ -1,9 +1,15 @@
 package rx
 
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
 // StartWith returns an Observable that emits the items you specify as arguments before it begins to emit items emitted by the source Observable.
 func StartWith[T any](values ...T) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			for len(values) > 0 {
 				if !yield(values[0], nil) {
 					return
@@ -11,7 +17,7 @@ func StartWith[T any](values ...T) OperatorFunc[T, T] {
 				values = values[1:]
 			}
 
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -24,3 +30,85 @@ func StartWith[T any](values ...T) OperatorFunc[T, T] {
 		})
 	}
 }
+
+// WithLatestFrom combines the source Observable with the latest value of the other Observable into a Tuple, only when the source emits.
+// The values emitted by the source before the other Observable emits are dropped.
+func WithLatestFrom[T, A any](a Observable[A]) OperatorFunc[T, Tuple[T, A]] {
+	return func(input Observable[T]) Observable[Tuple[T, A]] {
+		return Pipe1(
+			withLatestFrom(input, asAny(a)),
+			Map(func(v Tuple[T, []any], _ int) Tuple[T, A] {
+				return NewTuple(v.Left, fromAny[A](v.Right[0]))
+			}),
+		)
+	}
+}
+
+// WithLatestFrom2 is similar to WithLatestFrom but combines the source Observable with the latest values of two other Observables.
+func WithLatestFrom2[T, A, B any](a Observable[A], b Observable[B]) OperatorFunc[T, Tuple3[T, A, B]] {
+	return func(input Observable[T]) Observable[Tuple3[T, A, B]] {
+		return Pipe1(
+			withLatestFrom(input, asAny(a), asAny(b)),
+			Map(func(v Tuple[T, []any], _ int) Tuple3[T, A, B] {
+				return NewTuple3(v.Left, fromAny[A](v.Right[0]), fromAny[B](v.Right[1]))
+			}),
+		)
+	}
+}
+
+// WithLatestFrom3 is similar to WithLatestFrom but combines the source Observable with the latest values of three other Observables.
+func WithLatestFrom3[T, A, B, C any](a Observable[A], b Observable[B], c Observable[C]) OperatorFunc[T, Tuple4[T, A, B, C]] {
+	return func(input Observable[T]) Observable[Tuple4[T, A, B, C]] {
+		return Pipe1(
+			withLatestFrom(input, asAny(a), asAny(b), asAny(c)),
+			Map(func(v Tuple[T, []any], _ int) Tuple4[T, A, B, C] {
+				return NewTuple4(v.Left, fromAny[A](v.Right[0]), fromAny[B](v.Right[1]), fromAny[C](v.Right[2]))
+			}),
+		)
+	}
+}
+
+func withLatestFrom[T any](input Observable[T], others ...Observable[any]) Observable[Tuple[T, []any]] {
+	return (ObservableContextFunc[Tuple[T, []any]])(func(ctx context.Context, yield func(Tuple[T, []any], error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		source := subscribeAsync(ctx, &wg, input)
+		ch := subscribeEach(ctx, &wg, others)
+
+		latest := make([]any, len(others))
+		received := make([]bool, len(others))
+		var counter int
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case o := <-ch:
+				// The completion of the other Observables doesn't complete the resulting Observable.
+				if o.err != nil {
+					yield(Tuple[T, []any]{}, o.err)
+					return
+				} else if o.ok {
+					if !received[o.idx] {
+						received[o.idx] = true
+						counter++
+					}
+					latest[o.idx] = o.v
+				}
+			case o := <-source:
+				if o.err != nil {
+					yield(Tuple[T, []any]{}, o.err)
+					return
+				} else if !o.ok {
+					return
+				} else if counter >= len(others) {
+					if !yield(NewTuple(o.v, slices.Clone(latest)), nil) {
+						return
+					}
+				}
+			}
+		}
+	})
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"iter"
+	"slices"
 	"sync"
-	"sync/atomic"
 
 	"github.com/si3nloong/rx/internal/errgroup"
 )
@@ -15,77 +15,75 @@ func CombineLatest[T any](inputs ...Observable[T]) Observable[[]T] {
 	if len(inputs) < 2 {
 		panic(`CombineLatest required at least 2 observable`)
 	}
-	return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
-		inputCount := len(inputs)
-		ch := make(chan goState[T], 1)
-		defer close(ch)
-
+	return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
 		var wg sync.WaitGroup
-		ctx, cancel := context.WithCancel(context.Background())
+		defer wg.Wait()
 		defer cancel()
 
-		for i := range inputs {
-			wg.Go(func(index int, input Observable[T]) func() {
-				return func() {
-					next, stop := iter.Pull2(input.Subscribe())
-					defer stop()
-
-					for {
-						v, err, ok := next()
-						select {
-						case <-ctx.Done():
-							return
-						case ch <- goState[T]{index, v, err, ok}:
-							if err != nil || !ok {
-								return
-							}
-						}
-					}
-				}
-			}(i, inputs[i]))
-		}
+		ch := subscribeEach(ctx, &wg, inputs)
 
 		var counter int
 		idxCache := make(map[int]struct{})
 		defer clear(idxCache)
 		results := make([]T, len(inputs))
 
-	loop:
-		for o := range ch {
-			if o.err != nil {
-				// Propagate the error to all observable
-				cancel()
-				yield(nil, o.err)
+		for {
+			select {
+			case <-ctx.Done():
 				return
-			} else if !o.ok {
-				counter++
-				if counter >= inputCount {
-					break loop
-				}
-			} else {
-				idxCache[o.idx] = struct{}{}
-				results[o.idx] = o.v
-				if len(idxCache) >= inputCount {
-					if !yield(results, nil) {
-						cancel()
+			case o := <-ch:
+				if o.err != nil {
+					// Propagate the error to all observable
+					yield(nil, o.err)
+					return
+				} else if !o.ok {
+					counter++
+					if counter >= len(inputs) {
 						return
 					}
+				} else {
+					idxCache[o.idx] = struct{}{}
+					results[o.idx] = o.v
+					if len(idxCache) >= len(inputs) {
+						if !yield(slices.Clone(results), nil) {
+							return
+						}
+					}
 				}
 			}
 		}
-
-		wg.Wait()
 	})
 }
 
+// CombineLatest2 is similar to CombineLatest but combines two Observables of different types into a Tuple.
+func CombineLatest2[A, B any](a Observable[A], b Observable[B]) Observable[Tuple[A, B]] {
+	return Pipe1(
+		CombineLatest(asAny(a), asAny(b)),
+		Map(func(v []any, _ int) Tuple[A, B] {
+			return NewTuple(fromAny[A](v[0]), fromAny[B](v[1]))
+		}),
+	)
+}
+
+// CombineLatest3 is similar to CombineLatest but combines three Observables of different types into a Tuple3.
+func CombineLatest3[A, B, C any](a Observable[A], b Observable[B], c Observable[C]) Observable[Tuple3[A, B, C]] {
+	return Pipe1(
+		CombineLatest(asAny(a), asAny(b), asAny(c)),
+		Map(func(v []any, _ int) Tuple3[A, B, C] {
+			return NewTuple3(fromAny[A](v[0]), fromAny[B](v[1]), fromAny[C](v[2]))
+		}),
+	)
+}
+
 // Concat concatenates multiple Observables together by subscribing to them one at a time.
 func Concat[T any](inputs ...Observable[T]) Observable[T] {
 	if len(inputs) < 2 {
 		panic(`Concat required at least 2 observable`)
 	}
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
-		for len(inputs) > 0 {
-			for v, err := range inputs[0].Subscribe() {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		for _, input := range inputs {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -96,8 +94,6 @@ func Concat[T any](inputs ...Observable[T]) Observable[T] {
 					}
 				}
 			}
-			// Unshift the slice
-			inputs = inputs[1:]
 		}
 	})
 }
@@ -107,13 +103,13 @@ func ForkJoin[T any](inputs ...Observable[T]) Observable[[]T] {
 	if len(inputs) < 2 {
 		panic(`ForkJoin required at least 2 observable`)
 	}
-	return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
-		g, ctx := errgroup.WithContext(context.Background())
+	return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+		g, ctx := errgroup.WithContext(ctx)
 		results := make([]T, len(inputs))
 		for i, v := range inputs {
 			g.Go(func(index int, input Observable[T]) func() error {
 				return func() error {
-					next, stop := iter.Pull2(input.Subscribe())
+					next, stop := iter.Pull2(input.SubscribeContext(ctx))
 					defer stop()
 
 					v, err, ok := next()
@@ -161,107 +157,234 @@ func Merge[T any](inputs ...Observable[T]) Observable[T] {
 	if len(inputs) < 2 {
 		panic(`Merge required at least 2 observable`)
 	}
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
-		ch := make(chan T, 1)
-
-		g, ctx := errgroup.WithContext(context.Background())
-		for i, v := range inputs {
-			g.Go(func(index int, input Observable[T]) func() error {
-				return func() error {
-					next, stop := iter.Pull2(input.Subscribe())
-					defer stop()
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
 
-					for {
-						v, err, ok := next()
-						if err != nil {
-							return err
-						} else if !ok {
-							return nil
-						} else {
-							ch <- v
-						}
-					}
-				}
-			}(i, v))
-		}
-		go func() {
-			defer close(ch)
+		ch := subscribeEach(ctx, &wg, inputs)
 
-			for {
-				select {
-				case <-ctx.Done():
+		var counter int
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case o := <-ch:
+				if o.err != nil {
+					var zero T
+					yield(zero, o.err)
 					return
-				case v := <-ch:
-					if !yield(v, nil) {
+				} else if !o.ok {
+					counter++
+					if counter >= len(inputs) {
+						return
+					}
+				} else {
+					if !yield(o.v, nil) {
 						return
 					}
 				}
 			}
-		}()
-		if err := g.Wait(); err != nil {
-			var zero T
-			yield(zero, err)
-			return
 		}
 	})
 }
 
+// Merge2 is similar to Merge but merges two Observables of different types, each value tells which Observable emitted it.
+func Merge2[A, B any](a Observable[A], b Observable[B]) Observable[Either[A, B]] {
+	return Merge(
+		Pipe1(a, Map(func(v A, _ int) Either[A, B] { return NewA[B](v) })),
+		Pipe1(b, Map(func(v B, _ int) Either[A, B] { return NewB[A](v) })),
+	)
+}
+
+// Merge3 is similar to Merge but merges three Observables of different types, each value tells which Observable emitted it.
+func Merge3[A, B, C any](a Observable[A], b Observable[B], c Observable[C]) Observable[OneOf3[A, B, C]] {
+	return Merge(
+		Pipe1(a, Map(func(v A, _ int) OneOf3[A, B, C] { return NewOneOf3A[B, C](v) })),
+		Pipe1(b, Map(func(v B, _ int) OneOf3[A, B, C] { return NewOneOf3B[A, C](v) })),
+		Pipe1(c, Map(func(v C, _ int) OneOf3[A, B, C] { return NewOneOf3C[A, B](v) })),
+	)
+}
+
 // Race returns an Observable that mirrors the first source Observable to emit an item.
 func Race[T any](inputs ...Observable[T]) Observable[T] {
 	if len(inputs) < 2 {
 		panic(`Race required at least 2 observable`)
 	}
-	return (ObservableFunc[T])(func(yield func(T, error) bool) {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 		var wg sync.WaitGroup
-		var once sync.Once
-		var selectedIndex int32
+		defer wg.Wait()
 
-		for i := range inputs {
-			wg.Go(func(index int, input Observable[T]) func() {
-				return func() {
-					next, stop := iter.Pull2(input.Subscribe())
-					defer stop()
+		ch := make(chan goState[T], len(inputs))
+		cancels := make([]context.CancelFunc, len(inputs))
+		defer func() {
+			for _, cancel := range cancels {
+				cancel()
+			}
+		}()
 
-					// Peek the first emission
-					v, err, ok := next()
-					if !ok {
+		for i := range inputs {
+			ctx, cancel := context.WithCancel(ctx)
+			cancels[i] = cancel
+			wg.Go(func() {
+				for v, err := range inputs[i].SubscribeContext(ctx) {
+					select {
+					case <-ctx.Done():
 						return
-					} else {
-						once.Do(func() {
-							atomic.StoreInt32(&selectedIndex, (int32)(index))
-						})
-						if atomic.LoadInt32(&selectedIndex) != (int32)(index) {
+					case ch <- goState[T]{i, v, err, true}:
+					}
+					if err != nil {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+				case ch <- goState[T]{idx: i}:
+				}
+			})
+		}
+
+		selectedIndex := -1
+		var counter int
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case o := <-ch:
+				if selectedIndex < 0 {
+					if !o.ok {
+						counter++
+						if counter >= len(inputs) {
 							return
 						}
-						// If one of the used source observable throws an errors before a first notification the race operator will also throw an error, no matter if another source observable could potentially win the race.
-						if err != nil {
-							var zero T
-							yield(zero, err)
-							return
+						continue
+					}
+					// As soon as one of the source observables emits a value, the result unsubscribes from the other sources.
+					selectedIndex = o.idx
+					for i, cancel := range cancels {
+						if i != selectedIndex {
+							cancel()
 						}
+					}
+				} else if o.idx != selectedIndex {
+					continue
+				}
 
-						// As soon as one of the source observables emits a value, the result unsubscribes from the other sources.
-						// The resulting observable will forward all notifications, including error and completion, from the "winning" source observable.
-						if !yield(v, nil) {
-							return
-						}
+				// The resulting observable will forward all notifications, including error and completion, from the "winning" source observable.
+				// If one of the used source observable throws an errors before a first notification the race operator will also throw an error,
+				// no matter if another source observable could potentially win the race.
+				if o.err != nil {
+					var zero T
+					yield(zero, o.err)
+					return
+				} else if !o.ok {
+					return
+				} else if !yield(o.v, nil) {
+					return
+				}
+			}
+		}
+	})
+}
 
-						for {
-							v, err, ok := next()
-							if err != nil {
-								return
-							} else if !ok {
-								return
-							} else {
-								if !yield(v, nil) {
-									return
-								}
-							}
+// Zip combines multiple Observables to create an Observable whose values are calculated from the values, in order, of each of its input Observables.
+// The resulting Observable completes as soon as one of the inputs completes and none of its values is left to be combined.
+func Zip[T any](inputs ...Observable[T]) Observable[[]T] {
+	if len(inputs) < 2 {
+		panic(`Zip required at least 2 observable`)
+	}
+	return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		ch := subscribeEach(ctx, &wg, inputs)
+
+		queues := make([][]T, len(inputs))
+		completed := make([]bool, len(inputs))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case o := <-ch:
+				if o.err != nil {
+					yield(nil, o.err)
+					return
+				} else if !o.ok {
+					// No more values can be combined once the completed input has nothing left in its queue.
+					if len(queues[o.idx]) == 0 {
+						return
+					}
+					completed[o.idx] = true
+					continue
+				} else {
+					queues[o.idx] = append(queues[o.idx], o.v)
+				}
+
+				for !slices.ContainsFunc(queues, func(q []T) bool { return len(q) == 0 }) {
+					results := make([]T, len(inputs))
+					for i := range queues {
+						results[i] = queues[i][0]
+						var zero T
+						queues[i][0] = zero
+						queues[i] = queues[i][1:]
+					}
+					if !yield(results, nil) {
+						return
+					}
+					for i := range queues {
+						if completed[i] && len(queues[i]) == 0 {
+							return
 						}
 					}
 				}
-			}(i, inputs[i]))
+			}
 		}
-		wg.Wait()
 	})
 }
+
+// Zip2 is similar to Zip but combines two Observables of different types into a Tuple.
+func Zip2[A, B any](a Observable[A], b Observable[B]) Observable[Tuple[A, B]] {
+	return Pipe1(
+		Zip(asAny(a), asAny(b)),
+		Map(func(v []any, _ int) Tuple[A, B] {
+			return NewTuple(fromAny[A](v[0]), fromAny[B](v[1]))
+		}),
+	)
+}
+
+// Zip3 is similar to Zip but combines three Observables of different types into a Tuple3.
+func Zip3[A, B, C any](a Observable[A], b Observable[B], c Observable[C]) Observable[Tuple3[A, B, C]] {
+	return Pipe1(
+		Zip(asAny(a), asAny(b), asAny(c)),
+		Map(func(v []any, _ int) Tuple3[A, B, C] {
+			return NewTuple3(fromAny[A](v[0]), fromAny[B](v[1]), fromAny[C](v[2]))
+		}),
+	)
+}
+
+// Zip4 is similar to Zip but combines four Observables of different types into a Tuple4.
+func Zip4[A, B, C, D any](a Observable[A], b Observable[B], c Observable[C], d Observable[D]) Observable[Tuple4[A, B, C, D]] {
+	return Pipe1(
+		Zip(asAny(a), asAny(b), asAny(c), asAny(d)),
+		Map(func(v []any, _ int) Tuple4[A, B, C, D] {
+			return NewTuple4(fromAny[A](v[0]), fromAny[B](v[1]), fromAny[C](v[2]), fromAny[D](v[3]))
+		}),
+	)
+}
+
+// asAny erases the type of the values of input, so Observables of different types can be joined together.
+func asAny[T any](input Observable[T]) Observable[any] {
+	return Pipe1(input, Map(func(v T, _ int) any {
+		return v
+	}))
+}
+
+// fromAny restores the type of a value erased by asAny.
+// A nil interface value can't be asserted to an interface type, it's restored as the zero value of T.
+func fromAny[T any](v any) T {
+	t, _ := v.(T)
+	return t
+}
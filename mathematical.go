@@ -1,14 +1,16 @@
 package rx
 
+import "context"
+
 // Count counts the number of emissions on the source and emits that number when the source completes.
 func Count[T Number](predicate ...func(value T, index int) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var count T
 			if len(predicate) > 0 {
 				var i int
 				fn := predicate[0]
-				for v, err := range input.Subscribe() {
+				for v, err := range input.SubscribeContext(ctx) {
 					if err != nil {
 						var zero T
 						yield(zero, err)
@@ -20,7 +22,7 @@ func Count[T Number](predicate ...func(value T, index int) bool) OperatorFunc[T,
 					i++
 				}
 			} else {
-				for _, err := range input.Subscribe() {
+				for _, err := range input.SubscribeContext(ctx) {
 					if err != nil {
 						var zero T
 						yield(zero, err)
@@ -39,9 +41,9 @@ func Count[T Number](predicate ...func(value T, index int) bool) OperatorFunc[T,
 // Min emits the item from the source Observable that had the minimum value.
 func Min[T Number]() OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var minValue T
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -57,9 +59,9 @@ func Min[T Number]() OperatorFunc[T, T] {
 // Max emits the item from the source Observable that had the maximum value.
 func Max[T Number]() OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var maxValue T
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -75,12 +77,12 @@ func Max[T Number]() OperatorFunc[T, T] {
 // Reduce applies an accumulator function over the source Observable, and returns the accumulated result when the source completes, given an optional seed value.
 func Reduce[V, A any](accumulator func(acc A, value V, index int) A, seed A) OperatorFunc[V, A] {
 	return func(input Observable[V]) Observable[A] {
-		return (ObservableFunc[A])(func(yield func(A, error) bool) {
+		return (ObservableContextFunc[A])(func(ctx context.Context, yield func(A, error) bool) {
 			var (
 				acc = seed
 				i   int
 			)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero A
 					yield(zero, err)
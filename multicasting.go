@@ -0,0 +1,234 @@
+package rx
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+type connection struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Connectable is an Observable which multicasts the values of its source through a Subject.
+// The source is only subscribed once Connect is called, no matter how many subscribers there are.
+type Connectable[T any] struct {
+	source    Observable[T]
+	connector func() Subject[T]
+
+	// resetOnComplete decides whether a new Subject is created once the source completes,
+	// otherwise late subscribers keep receiving the notifications of the terminated Subject.
+	resetOnComplete bool
+
+	mu         sync.Mutex
+	subject    Subject[T]
+	conn       *connection
+	terminated bool
+	refCount   int
+}
+
+// NewConnectable creates a Connectable from the source Observable.
+// The optional connector creates the Subject used to multicast the values, it defaults to NewSubject.
+func NewConnectable[T any](source Observable[T], connector ...func() Subject[T]) *Connectable[T] {
+	c := &Connectable[T]{source: source, connector: NewSubject[T], resetOnComplete: true}
+	if len(connector) > 0 {
+		c.connector = connector[0]
+	}
+	return c
+}
+
+// Connect subscribes to the source and starts multicasting its values, it's a no-op if the Connectable is already connected.
+// The returned function disconnects from the source, which completes the current subscribers.
+func (c *Connectable[T]) Connect() (disconnect func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn := c.connect(context.Background())
+	return func() {
+		c.disconnect(conn)
+	}
+}
+
+// RefCount returns an Observable which automatically connects the Connectable when the first subscriber subscribes,
+// and disconnects it once the last subscriber unsubscribes.
+func (c *Connectable[T]) RefCount() Observable[T] {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		// The subscriber is counted along with the capture of the Subject,
+		// so the last of the other subscribers can't disconnect and drop it in the meantime.
+		c.mu.Lock()
+		subject := c.current()
+		c.refCount++
+		c.mu.Unlock()
+
+		subscribed := func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			// The Subject may have terminated with the source in the meantime, a new connection would have no subscriber.
+			if c.subject != subject {
+				return
+			}
+			// The connection outlives the first subscriber, so it only inherits the values of its context.
+			c.connect(context.WithoutCancel(ctx))
+		}
+		defer func() {
+			c.mu.Lock()
+			c.refCount--
+			conn := c.conn
+			if c.refCount > 0 || conn == nil {
+				c.mu.Unlock()
+				return
+			}
+			c.mu.Unlock()
+			c.disconnect(conn)
+		}()
+
+		if o, ok := subject.(hotObservable[T]); ok {
+			o.observe(ctx, yield, subscribed)
+			return
+		}
+		// Values emitted before the subscription of a foreign Subject may be missed.
+		subscribed()
+		for v, err := range subject.SubscribeContext(ctx) {
+			if !yield(v, err) {
+				return
+			}
+		}
+	})
+}
+
+// Subscribe returns an iterator that yields the values multicasted by the Connectable.
+func (c *Connectable[T]) Subscribe() iter.Seq2[T, error] {
+	return c.SubscribeContext(context.Background())
+}
+
+// SubscribeContext returns an iterator that yields the values multicasted by the Connectable until ctx is done.
+func (c *Connectable[T]) SubscribeContext(ctx context.Context) iter.Seq2[T, error] {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		c.mu.Lock()
+		subject := c.current()
+		c.mu.Unlock()
+
+		for v, err := range subject.SubscribeContext(ctx) {
+			if !yield(v, err) {
+				return
+			}
+		}
+	}).SubscribeContext(ctx)
+}
+
+// SubscribeOn subscribes to the Connectable and executes the provided callbacks for each event.
+func (c *Connectable[T]) SubscribeOn(onNext func(v T), onError func(err error), onComplete func()) {
+	subscribeOn(c.Subscribe(), onNext, onError, onComplete)
+}
+
+// current returns the Subject of the Connectable, it must be called while holding the lock.
+func (c *Connectable[T]) current() Subject[T] {
+	if c.subject == nil {
+		c.subject = c.connector()
+		c.terminated = false
+	}
+	return c.subject
+}
+
+// connect must be called while holding the lock.
+func (c *Connectable[T]) connect(ctx context.Context) *connection {
+	if c.conn != nil || c.terminated {
+		return c.conn
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	conn := &connection{cancel: cancel, done: make(chan struct{})}
+	subject := c.current()
+	c.conn = conn
+
+	go func() {
+		defer close(conn.done)
+
+		var err error
+		for v, e := range c.source.SubscribeContext(ctx) {
+			if e != nil {
+				err = e
+				break
+			}
+			subject.Next(v)
+		}
+		// The Subject is completed by disconnect.
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err != nil {
+			subject.Error(err)
+		} else {
+			subject.Complete()
+		}
+		if c.conn != conn {
+			return
+		}
+		c.conn = nil
+		if err != nil || c.resetOnComplete {
+			c.subject = nil
+		} else {
+			c.terminated = true
+		}
+	}()
+	return conn
+}
+
+func (c *Connectable[T]) disconnect(conn *connection) {
+	if conn == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
+	subject := c.subject
+	c.subject = nil
+	c.mu.Unlock()
+
+	conn.cancel()
+	<-conn.done
+	subject.Complete()
+}
+
+// RefCount makes the source Observable shared, it is subscribed when the first subscriber subscribes,
+// and unsubscribed once the last subscriber unsubscribes.
+// If the source is a Connectable, its own RefCount is used.
+func RefCount[T any]() OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		if c, ok := input.(*Connectable[T]); ok {
+			return c.RefCount()
+		}
+		return NewConnectable(input).RefCount()
+	}
+}
+
+// Share returns a new Observable that multicasts (shares) the original Observable.
+// As long as there is at least one subscriber, the source is subscribed once and its values are emitted to every subscriber.
+// Once the source completes or errors, or when there are no more subscribers, a new subscriber resubscribes to the source.
+func Share[T any]() OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return NewConnectable(input).RefCount()
+	}
+}
+
+// ShareReplay is similar to Share but replays at most bufferSize values, no older than windowTime, to late subscribers.
+// Once the source completes, late subscribers receive the replayed values without resubscribing to the source.
+// A bufferSize or a windowTime lower or equal to zero means the replay buffer isn't bounded by it.
+func ShareReplay[T any](bufferSize int, windowTime time.Duration) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		c := NewConnectable(input, func() Subject[T] {
+			return NewReplaySubject[T](bufferSize, windowTime)
+		})
+		c.resetOnComplete = false
+		return c.RefCount()
+	}
+}
@@ -1,7 +1,12 @@
 package rx
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"iter"
+	"sync"
+	"sync/atomic"
 )
 
 // ObservableFunc is a function type that implements the Observable interface.
@@ -33,23 +38,69 @@ func (fn ObservableFunc[T]) Subscribe() iter.Seq2[T, error] {
 	return (iter.Seq2[T, error])(fn)
 }
 
+// SubscribeContext returns an iterator that yields values and errors from the Observable until ctx is done.
+// Once ctx is done, the iterator yields ctx.Err() and stops.
+// Since the underlying function is not aware of ctx, a producer blocked between two emissions is only stopped on its next emission.
+func (fn ObservableFunc[T]) SubscribeContext(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		emit, finish := guard(ctx, yield)
+		if ctx.Err() == nil {
+			fn(emit)
+		}
+		finish()
+	}
+}
+
 // SubscribeOn subscribes to the Observable and executes the provided callbacks for each event.
 // onNext is called for each value emitted.
 // onError is called if an error occurs.
 // onComplete is called when the Observable completes successfully.
 func (fn ObservableFunc[T]) SubscribeOn(onNext func(v T), onError func(err error), onComplete func()) {
-	next, stop := iter.Pull2((iter.Seq2[T, error])(fn))
+	subscribeOn(fn.Subscribe(), onNext, onError, onComplete)
+}
+
+// ObservableContextFunc is similar to ObservableFunc but the function also receives the context of the subscription.
+// The producer should stop as soon as ctx is done, the stream is then ended with ctx.Err().
+type ObservableContextFunc[T any] func(ctx context.Context, yield func(T, error) bool)
+
+// Subscribe returns an iterator that yields values and errors from the Observable.
+// It is a shorthand for SubscribeContext(context.Background()).
+func (fn ObservableContextFunc[T]) Subscribe() iter.Seq2[T, error] {
+	return fn.SubscribeContext(context.Background())
+}
+
+// SubscribeContext returns an iterator that yields values and errors from the Observable until ctx is done.
+// Once ctx is done, the iterator yields ctx.Err() and stops.
+func (fn ObservableContextFunc[T]) SubscribeContext(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		emit, finish := guard(ctx, yield)
+		if ctx.Err() == nil {
+			fn(ctx, emit)
+		}
+		finish()
+	}
+}
+
+// SubscribeOn subscribes to the Observable and executes the provided callbacks for each event.
+func (fn ObservableContextFunc[T]) SubscribeOn(onNext func(v T), onError func(err error), onComplete func()) {
+	subscribeOn(fn.Subscribe(), onNext, onError, onComplete)
+}
+
+// subscribeOn calls the callbacks for each event of seq, a nil callback is ignored.
+func subscribeOn[T any](seq iter.Seq2[T, error], onNext func(v T), onError func(err error), onComplete func()) {
+	observer := PartialObserver[T]{onNext, onError, onComplete}
+	next, stop := iter.Pull2(seq)
 	defer stop()
 
 	for {
 		if v, err, ok := next(); err != nil {
-			onError(err)
+			observer.Error(err)
 			return
 		} else if !ok {
-			onComplete()
+			observer.Complete()
 			return
 		} else {
-			onNext(v)
+			observer.Next(v)
 		}
 	}
 }
@@ -193,3 +244,156 @@ func Pipe10[I, O1, O2, O3, O4, O5, O6, O7, O8, O9, O10 any](
 ) Observable[O10] {
 	return Observable[O10](f10(f9(f8(f7(f6(f5(f4(f3(f2(f1(input)))))))))))
 }
+
+// Compose combines any number of operator functions of the same type into one, applied from left to right.
+//
+// Example:
+//
+//	rx.Pipe1(
+//		rx.Of(1, 2, 3),
+//		rx.Compose(
+//			rx.Map(func(i int, _ int) int { return i * 2 }),
+//			rx.Filter(func(i int) bool { return i > 2 }),
+//		),
+//	)
+func Compose[T any](ops ...OperatorFunc[T, T]) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		for _, op := range ops {
+			input = op(input)
+		}
+		return input
+	}
+}
+
+// Chain is an Observable built stage by stage, without the limit of arity of the Pipe functions.
+// Pipe adds stages of the same type, while Then adds a stage changing the type of the values.
+//
+// Example:
+//
+//	chain := rx.Then(
+//		rx.NewChain(rx.Of(1, 2, 3)).Pipe(
+//			rx.Map(func(i int, _ int) int { return i * 2 }),
+//			rx.Filter(func(i int) bool { return i > 2 }),
+//		),
+//		rx.Map(func(i int, _ int) string { return strconv.Itoa(i) }),
+//	)
+//	for v, err := range chain.Subscribe() { ... }
+type Chain[T any] struct {
+	Observable[T]
+}
+
+// NewChain starts a Chain from the input Observable.
+func NewChain[T any](input Observable[T]) Chain[T] {
+	return Chain[T]{input}
+}
+
+// Pipe returns a Chain with the operator functions added as its last stages.
+func (c Chain[T]) Pipe(ops ...OperatorFunc[T, T]) Chain[T] {
+	return Chain[T]{Compose(ops...)(c.Observable)}
+}
+
+// Then returns a Chain with the operator function, which may change the type of the values, added as its last stage.
+func Then[I, O any](c Chain[I], op OperatorFunc[I, O]) Chain[O] {
+	return Chain[O]{op(c.Observable)}
+}
+
+// StageError is the error emitted by a stage named with Named.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("rxgo: stage %q: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// StagePanic is the value a stage named with Named panics with, when a panic originates from it.
+type StagePanic struct {
+	Stage string
+	Value any
+}
+
+func (p *StagePanic) Error() string {
+	return fmt.Sprintf("rxgo: stage %q panicked: %v", p.Stage, p.Value)
+}
+
+// Unwrap returns the value of the original panic, if it's an error.
+func (p *StagePanic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// Named names the stage made of the operator function: the errors originating from it are wrapped in a StageError,
+// while the errors of the previous stages go through as is.
+// Likewise, a panic originating from the stage is turned into a StagePanic, unless it occurs on another goroutine.
+func Named[I, O any](name string, op OperatorFunc[I, O]) OperatorFunc[I, O] {
+	return func(input Observable[I]) Observable[O] {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
+			var (
+				mu       sync.Mutex
+				upstream error
+				// upstreamPanic is set when a panic originates from the previous stages.
+				upstreamPanic atomic.Bool
+			)
+			source := (ObservableContextFunc[I])(func(ctx context.Context, yield func(I, error) bool) {
+				yielding := false
+				defer func() {
+					if !yielding {
+						if r := recover(); r != nil {
+							upstreamPanic.Store(true)
+							panic(r)
+						}
+					}
+				}()
+				for v, err := range input.SubscribeContext(ctx) {
+					yielding = true
+					if err != nil {
+						mu.Lock()
+						upstream = err
+						mu.Unlock()
+						yield(v, err)
+						return
+					} else {
+						if !yield(v, nil) {
+							return
+						}
+					}
+					yielding = false
+				}
+			})
+
+			// The panics of the consumer go through as is too.
+			yielding := false
+			defer func() {
+				if !yielding && !upstreamPanic.Load() {
+					if r := recover(); r != nil {
+						panic(&StagePanic{Stage: name, Value: r})
+					}
+				}
+			}()
+			for v, err := range op(source).SubscribeContext(ctx) {
+				yielding = true
+				if err != nil {
+					mu.Lock()
+					passThrough := upstream != nil && errors.Is(err, upstream)
+					mu.Unlock()
+					if !passThrough && (ctx.Err() == nil || !errors.Is(err, ctx.Err())) {
+						err = &StageError{Stage: name, Err: err}
+					}
+					var zero O
+					yield(zero, err)
+					return
+				} else {
+					if !yield(v, nil) {
+						return
+					}
+				}
+				yielding = false
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package rx
+
+import (
+	"context"
+	"sync"
+)
+
+// Observer is a consumer of the notifications of an Observable.
+// Every Subject is an Observer, so an Observable can be subscribed to a Subject with SubscribeWith.
+type Observer[T any] interface {
+	// Next receives each value emitted.
+	Next(v T)
+	// Error receives the error terminating the Observable.
+	Error(err error)
+	// Complete is called when the Observable completes.
+	Complete()
+}
+
+var _ Observer[any] = (Subject[any])(nil)
+
+// PartialObserver is an Observer made of callbacks, a nil callback is ignored.
+type PartialObserver[T any] struct {
+	OnNext     func(v T)
+	OnError    func(err error)
+	OnComplete func()
+}
+
+// Next calls OnNext, if it's set.
+func (o PartialObserver[T]) Next(v T) {
+	if o.OnNext != nil {
+		o.OnNext(v)
+	}
+}
+
+// Error calls OnError, if it's set.
+func (o PartialObserver[T]) Error(err error) {
+	if o.OnError != nil {
+		o.OnError(err)
+	}
+}
+
+// Complete calls OnComplete, if it's set.
+func (o PartialObserver[T]) Complete() {
+	if o.OnComplete != nil {
+		o.OnComplete()
+	}
+}
+
+// SubscribeWith subscribes to the Observable and sends its notifications to the Observer, it blocks until the Observable terminates.
+// Once ctx is done, the Observer receives ctx.Err().
+func SubscribeWith[T any](ctx context.Context, input Observable[T], observer Observer[T]) {
+	subscribeOn(input.SubscribeContext(ctx), observer.Next, observer.Error, observer.Complete)
+}
+
+// SubscribeAsyncWith is similar to SubscribeAsync but sends the notifications to the Observer.
+func SubscribeAsyncWith[T any](ctx context.Context, input Observable[T], observer Observer[T]) Subscription {
+	return SubscribeAsync(ctx, input, observer.Next, observer.Error, observer.Complete)
+}
+
+type observerSubject[T any] struct {
+	*subject[T]
+	mu       sync.Mutex
+	observer Observer[T]
+	stopped  bool
+}
+
+// NewSubjectFrom creates a Subject which forwards its notifications to the Observer, on top of its subscribers.
+// The Observer receives the notifications one at a time, and none once the Subject is terminated.
+func NewSubjectFrom[T any](observer Observer[T]) Subject[T] {
+	return &observerSubject[T]{subject: &subject[T]{}, observer: observer}
+}
+
+func (s *observerSubject[T]) Next(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.observer.Next(v)
+	s.subject.Next(v)
+}
+
+func (s *observerSubject[T]) Error(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	s.observer.Error(err)
+	s.subject.Error(err)
+}
+
+func (s *observerSubject[T]) Complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	s.observer.Complete()
+	s.subject.Complete()
+}
@@ -1,8 +1,10 @@
 package rx
 
 import (
+	"context"
 	"errors"
 	"iter"
+	"sync"
 )
 
 // Number is a generic constraints that represents all numeric types in Go.
@@ -29,7 +31,9 @@ type Iterator2[T any, K comparable] interface {
 type Observable[T any] interface {
 	// Subscribe returns an iterator that yields values and errors from the Observable.
 	Subscribe() iter.Seq2[T, error]
-	// SubscribeOn subscribes to the Observable and executes the provided callbacks for each event.
+	// SubscribeContext is similar to Subscribe but ends the stream with ctx.Err() once ctx is done.
+	SubscribeContext(ctx context.Context) iter.Seq2[T, error]
+	// SubscribeOn subscribes to the Observable and executes the provided callbacks for each event, a nil callback is ignored.
 	SubscribeOn(onNext func(T), onFailed func(error), onCompleted func())
 }
 
@@ -51,6 +55,9 @@ var ErrArgumentOutOfRange = errors.New(`rxgo: out of range`)
 // ErrSequence is returned when a sequence contains too many values (e.g. Single operator).
 var ErrSequence = errors.New(`rxgo: too many values match`)
 
+// ErrBufferOverflow is returned when a bounded buffer is full (e.g. OnBackpressureBuffer operator).
+var ErrBufferOverflow = errors.New(`rxgo: buffer overflow`)
+
 var errEmptyObservable = errors.New("rxgo: empty observable")
 
 type state[T any] struct {
@@ -65,3 +72,237 @@ type goState[T any] struct {
 	err error
 	ok  bool
 }
+
+// guard wraps yield so that the stream ends with ctx.Err() once ctx is done.
+// It also drops every emission after an error or after the consumer has stopped,
+// so a producer can never resume an iteration that has already ended.
+func guard[T any](ctx context.Context, yield func(T, error) bool) (func(T, error) bool, func()) {
+	var done bool
+	emit := func(v T, err error) bool {
+		if done {
+			return false
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			done = true
+			var zero T
+			yield(zero, err)
+			return false
+		}
+		if !yield(v, nil) {
+			done = true
+			return false
+		}
+		return true
+	}
+	// If the producer returns because ctx is done, the stream still has to end with ctx.Err().
+	finish := func() {
+		if done {
+			return
+		}
+		if err := ctx.Err(); err != nil {
+			done = true
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return emit, finish
+}
+
+// subscribeAsync subscribes to input on a new goroutine and forwards its notifications to the returned channel.
+// A notification with ok == false signals the completion of input.
+// The goroutine exits as soon as ctx is done; wg is used to wait for it.
+func subscribeAsync[T any](ctx context.Context, wg *sync.WaitGroup, input Observable[T]) <-chan state[T] {
+	ch := make(chan state[T], 1)
+	wg.Go(func() {
+		for v, err := range input.SubscribeContext(ctx) {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- state[T]{v, err, true}:
+			}
+			if err != nil {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case ch <- state[T]{}:
+		}
+	})
+	return ch
+}
+
+// subscribeEach subscribes to every input on its own goroutine and forwards their notifications,
+// tagged with the index of the input, to the returned channel.
+// A notification with ok == false signals the completion of the corresponding input.
+func subscribeEach[T any](ctx context.Context, wg *sync.WaitGroup, inputs []Observable[T]) <-chan goState[T] {
+	ch := make(chan goState[T], len(inputs))
+	for i := range inputs {
+		wg.Go(func(index int, input Observable[T]) func() {
+			return func() {
+				for v, err := range input.SubscribeContext(ctx) {
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- goState[T]{index, v, err, true}:
+					}
+					if err != nil {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+				case ch <- goState[T]{idx: index}:
+				}
+			}
+		}(i, inputs[i]))
+	}
+	return ch
+}
+
+// queue hands values over from a producer goroutine to a subscriber.
+// It's unbounded if limit is lower or equal to zero, otherwise push waits for room once it's full.
+type queue[T any] struct {
+	mu     sync.Mutex
+	items  []T
+	limit  int
+	closed bool
+	err    error
+	signal chan struct{}
+	space  chan struct{}
+}
+
+func newQueue[T any](limit int) *queue[T] {
+	return &queue[T]{limit: limit, signal: make(chan struct{}, 1), space: make(chan struct{}, 1)}
+}
+
+// push appends v to the queue, it reports false if the queue is closed or ctx is done before v could be appended.
+func (q *queue[T]) push(ctx context.Context, v T) bool {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return false
+		}
+		if q.limit <= 0 || len(q.items) < q.limit {
+			q.items = append(q.items, v)
+			q.mu.Unlock()
+			notify(q.signal)
+			return true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-q.space:
+		}
+	}
+}
+
+// offer appends v to the queue without blocking, overflowPolicy decides what happens if the queue is full.
+// It reports false if the queue is closed, and returns ErrBufferOverflow if it's full and overflowPolicy is OverflowError.
+func (q *queue[T]) offer(v T, overflowPolicy OverflowPolicy) (bool, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false, nil
+	}
+	if q.limit > 0 && len(q.items) >= q.limit {
+		if overflowPolicy == OverflowError {
+			q.mu.Unlock()
+			return true, ErrBufferOverflow
+		} else if overflowPolicy == OverflowDropNewest {
+			q.mu.Unlock()
+			return true, nil
+		} else {
+			var zero T
+			q.items[0] = zero
+			q.items = q.items[1:]
+		}
+	}
+	q.items = append(q.items, v)
+	q.mu.Unlock()
+	notify(q.signal)
+	return true, nil
+}
+
+// close terminates the queue with err, the values already pushed are still delivered.
+func (q *queue[T]) close(err error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed, q.err = true, err
+	q.mu.Unlock()
+	notify(q.signal)
+	notify(q.space)
+}
+
+// drain yields the values of the queue until it's closed or ctx is done.
+func (q *queue[T]) drain(ctx context.Context, yield func(T, error) bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0]
+			var zero T
+			q.items[0] = zero
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			notify(q.space)
+			if !yield(v, nil) {
+				return
+			}
+			continue
+		}
+		closed, err := q.closed, q.err
+		q.mu.Unlock()
+
+		if closed {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.signal:
+		}
+	}
+}
+
+// queueObservable is an Observable yielding the values of a queue, each value is delivered to a single subscriber.
+type queueObservable[T any] struct {
+	values *queue[T]
+}
+
+// Subscribe returns an iterator that yields the values of the queue.
+func (o queueObservable[T]) Subscribe() iter.Seq2[T, error] {
+	return o.SubscribeContext(context.Background())
+}
+
+// SubscribeContext returns an iterator that yields the values of the queue until ctx is done.
+func (o queueObservable[T]) SubscribeContext(ctx context.Context) iter.Seq2[T, error] {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		o.values.drain(ctx, yield)
+	}).SubscribeContext(ctx)
+}
+
+// SubscribeOn subscribes to the queue and executes the provided callbacks for each event.
+func (o queueObservable[T]) SubscribeOn(onNext func(v T), onError func(err error), onComplete func()) {
+	subscribeOn(o.Subscribe(), onNext, onError, onComplete)
+}
+
+// notify wakes up the goroutine waiting on ch, if any.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
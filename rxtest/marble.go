@@ -0,0 +1,149 @@
+package rxtest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/si3nloong/rx"
+)
+
+// Kind is the kind of a notification described by a marble diagram.
+type Kind = rx.NotificationKind
+
+const (
+	// KindNext is a value notification.
+	KindNext = rx.KindNext
+	// KindError is an error notification.
+	KindError = rx.KindError
+	// KindComplete is a completion notification.
+	KindComplete = rx.KindComplete
+)
+
+type event struct {
+	frame int
+	kind  Kind
+	key   string
+}
+
+// parseMarble parses a marble diagram into its notifications.
+// Every character takes one frame of virtual time, except the whitespaces which are ignored:
+//
+//   - '-' is the passing of one frame.
+//   - '|' is the completion.
+//   - '#' is an error.
+//   - '^' is the subscription point, the frames are relative to it (hot Observables only).
+//   - '(' and ')' group the notifications emitted synchronously, at the frame of '('.
+//   - any other character is a value, looked up by key.
+func parseMarble(marble string, allowSubscription bool) ([]event, error) {
+	var (
+		events  []event
+		frame   int
+		origin  int
+		group   = -1
+		hasHead bool
+	)
+	for i, r := range marble {
+		if r == ' ' || r == '\t' || r == '\n' {
+			continue
+		}
+		at := frame
+		if group >= 0 {
+			at = group
+		}
+		switch r {
+		case '-':
+		case '^':
+			if !allowSubscription {
+				return nil, fmt.Errorf("rxtest: unexpected subscription point at %d in %q", i, marble)
+			}
+			if hasHead {
+				return nil, fmt.Errorf("rxtest: found a second subscription point at %d in %q", i, marble)
+			}
+			hasHead, origin = true, frame
+		case '(':
+			if group >= 0 {
+				return nil, fmt.Errorf("rxtest: nested group at %d in %q", i, marble)
+			}
+			group = frame
+		case ')':
+			if group < 0 {
+				return nil, fmt.Errorf("rxtest: unexpected end of group at %d in %q", i, marble)
+			}
+			group = -1
+		case '|':
+			events = append(events, event{frame: at, kind: KindComplete})
+		case '#':
+			events = append(events, event{frame: at, kind: KindError})
+		case '!':
+			return nil, fmt.Errorf("rxtest: unexpected unsubscription point at %d in %q", i, marble)
+		default:
+			events = append(events, event{frame: at, kind: KindNext, key: string(r)})
+		}
+		frame++
+	}
+	if group >= 0 {
+		return nil, fmt.Errorf("rxtest: unterminated group in %q", marble)
+	}
+	for i := range events {
+		events[i].frame -= origin
+	}
+	return events, nil
+}
+
+// SubscriptionLog records the frames of a subscription.
+// Unsubscribed is -1 if the subscription was still active at the end of the test.
+type SubscriptionLog struct {
+	Subscribed   int
+	Unsubscribed int
+}
+
+func (l SubscriptionLog) String() string {
+	if l.Unsubscribed < 0 {
+		return fmt.Sprintf("^%d", l.Subscribed)
+	}
+	return fmt.Sprintf("^%d!%d", l.Subscribed, l.Unsubscribed)
+}
+
+// parseSubscription parses a subscription marble diagram such as "--^---!",
+// where '^' is the subscription point and '!' the unsubscription point.
+func parseSubscription(marble string) (SubscriptionLog, error) {
+	log := SubscriptionLog{Subscribed: -1, Unsubscribed: -1}
+	var frame int
+	group := -1
+	for i, r := range strings.TrimSpace(marble) {
+		if r == ' ' {
+			continue
+		}
+		at := frame
+		if group >= 0 {
+			at = group
+		}
+		switch r {
+		case '-':
+		case '(':
+			group = frame
+		case ')':
+			group = -1
+		case '^':
+			if log.Subscribed >= 0 {
+				return log, fmt.Errorf("rxtest: found a second subscription point at %d in %q", i, marble)
+			}
+			log.Subscribed = at
+		case '!':
+			if log.Unsubscribed >= 0 {
+				return log, fmt.Errorf("rxtest: found a second unsubscription point at %d in %q", i, marble)
+			}
+			log.Unsubscribed = at
+		default:
+			return log, fmt.Errorf("rxtest: unexpected character %q at %d in %q", r, i, marble)
+		}
+		frame++
+	}
+	if log.Subscribed < 0 {
+		return log, fmt.Errorf("rxtest: missing subscription point in %q", marble)
+	}
+	if log.Unsubscribed >= 0 && log.Unsubscribed < log.Subscribed {
+		return log, fmt.Errorf("rxtest: unsubscription point before subscription point in %q", marble)
+	}
+	return log, nil
+}
@@ -0,0 +1,66 @@
+package rxtest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarble(t *testing.T) {
+	t.Run("Cold", func(t *testing.T) {
+		events, err := parseMarble("-a-b-(cd)-#", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []event{
+			{1, KindNext, "a"},
+			{3, KindNext, "b"},
+			{5, KindNext, "c"},
+			{5, KindNext, "d"},
+			{10, KindError, ""},
+		}
+		if !reflect.DeepEqual(events, expected) {
+			t.Fatalf("expected %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("Hot", func(t *testing.T) {
+		events, err := parseMarble("-a-^-b-|", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []event{
+			{-2, KindNext, "a"},
+			{2, KindNext, "b"},
+			{4, KindComplete, ""},
+		}
+		if !reflect.DeepEqual(events, expected) {
+			t.Fatalf("expected %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, marble := range []string{"-(a(b))", "-a)", "-(a", "-^-^", "-a-!"} {
+			if _, err := parseMarble(marble, true); err == nil {
+				t.Errorf("expected an error for %q", marble)
+			}
+		}
+	})
+}
+
+func TestParseSubscription(t *testing.T) {
+	for marble, expected := range map[string]SubscriptionLog{
+		"^":        {0, -1},
+		"--^--!":   {2, 5},
+		"^-(!)":    {0, 2},
+		" -^- ! ":  {1, 3},
+		"--(^!)--": {2, 2},
+	} {
+		log, err := parseSubscription(marble)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log != expected {
+			t.Errorf("%q: expected %v, got %v", marble, expected, log)
+		}
+	}
+}
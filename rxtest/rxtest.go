@@ -0,0 +1,417 @@
+// Package rxtest provides marble testing for the Observables of the rx package.
+//
+// A marble diagram describes the notifications of an Observable over virtual time, one character per frame:
+//
+//	"-a-b-(cd)-#"
+//
+// Cold and Hot create test Observables from marble diagrams, ExpectObservable asserts the notifications of an
+// Observable against an expected marble diagram and ExpectSubscriptions asserts the subscription windows of a test Observable.
+// Everything runs on top of an rx.TestScheduler, so time-based operators are tested in virtual time,
+// within a synctest bubble, so the TestScheduler knows when the goroutines of the pipeline are done with a frame.
+//
+//	rxtest.Run(t, func(s *rxtest.Scheduler) {
+//		values := map[string]int{"a": 1, "b": 2, "c": 3}
+//		source := rxtest.Cold(s, "-a--b---c|", values, nil)
+//		rxtest.ExpectObservable(s, rx.Pipe1(source, rx.DebounceTime[int](2*rxtest.Frame))).
+//			ToBe("---a--b--(c|)", values, nil)
+//		s.ExpectSubscriptions(source).ToBe("^--------!")
+//	})
+package rxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/si3nloong/rx"
+)
+
+// Frame is the virtual duration of one character of a marble diagram.
+const Frame = time.Millisecond
+
+// ErrMarble is the error emitted by '#' when no error is given.
+var ErrMarble = errors.New(`rxtest: error`)
+
+// Scheduler runs marble tests on top of an rx.TestScheduler.
+type Scheduler struct {
+	*rx.TestScheduler
+
+	// MaxFrames bounds the virtual time of a test, Observables which never complete are unsubscribed at this frame.
+	MaxFrames int
+
+	t       testing.TB
+	start   time.Time
+	starts  []func(ctx context.Context, wg *sync.WaitGroup)
+	asserts []func()
+	ended   atomic.Bool
+}
+
+// NewScheduler creates a Scheduler, the assertions are reported to t once Flush is called.
+// It must be created and flushed in a synctest bubble, see synctest.Test.
+func NewScheduler(t testing.TB) *Scheduler {
+	scheduler := rx.NewTestScheduler(synctest.Wait)
+	return &Scheduler{TestScheduler: scheduler, MaxFrames: 750, t: t, start: scheduler.Now()}
+}
+
+// Run creates a Scheduler in a new synctest bubble, calls fn to set up the test Observables and the expectations, then flushes it.
+func Run(t *testing.T, fn func(s *Scheduler)) {
+	t.Helper()
+	synctest.Test(t, func(t *testing.T) {
+		s := NewScheduler(t)
+		fn(s)
+		s.Flush()
+	})
+}
+
+// Flush subscribes to the test Observables, moves the virtual time forward until MaxFrames,
+// unsubscribes from every Observable still active, then checks the expectations.
+func (s *Scheduler) Flush() {
+	s.t.Helper()
+
+	ctx, cancel := context.WithCancel(rx.WithClock(context.Background(), s.TestScheduler))
+	var wg sync.WaitGroup
+	for _, start := range s.starts {
+		start(ctx, &wg)
+	}
+	s.starts = nil
+
+	s.AdvanceTo(s.at(s.MaxFrames))
+	s.ended.Store(true)
+	cancel()
+	wg.Wait()
+
+	for _, assert := range s.asserts {
+		assert()
+	}
+	s.asserts = nil
+}
+
+// Frame returns the current frame of the virtual time.
+func (s *Scheduler) Frame() int {
+	return int(s.Now().Sub(s.start) / Frame)
+}
+
+func (s *Scheduler) at(frame int) time.Time {
+	return s.start.Add(time.Duration(frame) * Frame)
+}
+
+// sleepUntil waits until the virtual time reaches the given time, it reports false if ctx is done before.
+func (s *Scheduler) sleepUntil(ctx context.Context, t time.Time) bool {
+	d := t.Sub(s.Now())
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := s.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// Message is a notification recorded at a frame of virtual time.
+type Message[T any] struct {
+	Frame int
+	rx.Notification[T]
+}
+
+func (m Message[T]) String() string {
+	return fmt.Sprintf("%d:%s", m.Frame, m.Notification)
+}
+
+func (m Message[T]) equal(other Message[T]) bool {
+	if m.Frame != other.Frame || m.Kind != other.Kind {
+		return false
+	}
+	switch m.Kind {
+	case KindNext:
+		return reflect.DeepEqual(m.Value, other.Value)
+	case KindError:
+		return errors.Is(other.Err, m.Err) || m.Err.Error() == other.Err.Error()
+	}
+	return true
+}
+
+// messages converts the parsed events of a marble diagram into messages.
+func messages[T any](t testing.TB, marble string, events []event, values map[string]T, err error) []Message[T] {
+	t.Helper()
+	if err == nil {
+		err = ErrMarble
+	}
+	result := make([]Message[T], 0, len(events))
+	for _, e := range events {
+		m := Message[T]{Frame: e.frame, Notification: rx.Notification[T]{Kind: e.kind}}
+		switch e.kind {
+		case KindNext:
+			v, ok := values[e.key]
+			if !ok {
+				// Without values, a string Observable emits the keys themselves.
+				if key, isT := any(e.key).(T); isT {
+					v, ok = key, true
+				}
+			}
+			if !ok {
+				t.Fatalf("rxtest: no value for %q in %q", e.key, marble)
+			}
+			m.Value = v
+		case KindError:
+			m.Err = err
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// TestObservable is an Observable created from a marble diagram, which records its subscriptions.
+type TestObservable[T any] struct {
+	rx.Observable[T]
+
+	mu            sync.Mutex
+	subscriptions []SubscriptionLog
+}
+
+// Subscriptions returns the subscription windows recorded so far.
+func (o *TestObservable[T]) Subscriptions() []SubscriptionLog {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]SubscriptionLog(nil), o.subscriptions...)
+}
+
+func (o *TestObservable[T]) subscribe(s *Scheduler) func() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	i := len(o.subscriptions)
+	o.subscriptions = append(o.subscriptions, SubscriptionLog{Subscribed: s.Frame(), Unsubscribed: -1})
+	return func() {
+		// The subscriptions still active at the end of the test are left open.
+		if s.ended.Load() {
+			return
+		}
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.subscriptions[i].Unsubscribed = s.Frame()
+	}
+}
+
+// Cold creates an Observable which emits the notifications of the marble diagram, relatively to each subscription.
+// Values are looked up by their character in values, '#' emits err, or ErrMarble if err is nil.
+func Cold[T any](s *Scheduler, marble string, values map[string]T, err error) *TestObservable[T] {
+	s.t.Helper()
+	events, parseErr := parseMarble(marble, false)
+	if parseErr != nil {
+		s.t.Fatal(parseErr)
+	}
+	msgs := messages(s.t, marble, events, values, err)
+
+	o := &TestObservable[T]{}
+	o.Observable = (rx.ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		defer o.subscribe(s)()
+
+		origin := s.Now()
+		for _, m := range msgs {
+			if !s.sleepUntil(ctx, origin.Add(time.Duration(m.Frame)*Frame)) {
+				return
+			}
+			switch m.Kind {
+			case KindNext:
+				if !yield(m.Value, nil) {
+					return
+				}
+			case KindError:
+				var zero T
+				yield(zero, m.Err)
+				return
+			case KindComplete:
+				return
+			}
+		}
+		// Without a terminal notification, the Observable never completes.
+		<-ctx.Done()
+	})
+	return o
+}
+
+// Hot creates an Observable which emits the notifications of the marble diagram relatively to '^', the start of the test,
+// whether or not it is subscribed. Notifications before '^' are never received.
+// Values are looked up by their character in values, '#' emits err, or ErrMarble if err is nil.
+func Hot[T any](s *Scheduler, marble string, values map[string]T, err error) *TestObservable[T] {
+	s.t.Helper()
+	events, parseErr := parseMarble(marble, true)
+	if parseErr != nil {
+		s.t.Fatal(parseErr)
+	}
+	msgs := messages(s.t, marble, events, values, err)
+
+	subject := rx.NewSubject[T]()
+	o := &TestObservable[T]{}
+	o.Observable = (rx.ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		defer o.subscribe(s)()
+
+		for v, err := range subject.SubscribeContext(ctx) {
+			if !yield(v, err) {
+				return
+			}
+		}
+	})
+	s.starts = append(s.starts, func(ctx context.Context, wg *sync.WaitGroup) {
+		wg.Go(func() {
+			for _, m := range msgs {
+				if m.Frame < 0 {
+					continue
+				}
+				if !s.sleepUntil(ctx, s.at(m.Frame)) {
+					return
+				}
+				switch m.Kind {
+				case KindNext:
+					subject.Next(m.Value)
+				case KindError:
+					subject.Error(m.Err)
+				case KindComplete:
+					subject.Complete()
+				}
+			}
+		})
+	})
+	return o
+}
+
+// Expectation asserts the notifications of an Observable.
+type Expectation[T any] struct {
+	s        *Scheduler
+	mu       sync.Mutex
+	messages []Message[T]
+}
+
+// ExpectObservable subscribes to the Observable when the Scheduler is flushed and records its notifications.
+// The optional subscription marble diagram, such as "--^---!", sets when to subscribe and unsubscribe, it defaults to "^".
+func ExpectObservable[T any](s *Scheduler, observable rx.Observable[T], subscription ...string) *Expectation[T] {
+	s.t.Helper()
+	log := SubscriptionLog{Subscribed: 0, Unsubscribed: -1}
+	if len(subscription) > 0 {
+		var err error
+		if log, err = parseSubscription(subscription[0]); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	e := &Expectation[T]{s: s}
+	s.starts = append(s.starts, func(ctx context.Context, wg *sync.WaitGroup) {
+		ctx, cancel := context.WithCancel(ctx)
+		if log.Unsubscribed >= 0 {
+			wg.Go(func() {
+				if s.sleepUntil(ctx, s.at(log.Unsubscribed)) {
+					cancel()
+				}
+			})
+		}
+		wg.Go(func() {
+			defer cancel()
+			if !s.sleepUntil(ctx, s.at(log.Subscribed)) {
+				return
+			}
+			for v, err := range observable.SubscribeContext(ctx) {
+				if err != nil {
+					// The unsubscription isn't a notification.
+					if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+						return
+					}
+					e.record(Message[T]{Frame: s.Frame(), Notification: rx.ErrorNotification[T](err)})
+					return
+				}
+				e.record(Message[T]{Frame: s.Frame(), Notification: rx.NextNotification(v)})
+			}
+			e.record(Message[T]{Frame: s.Frame(), Notification: rx.CompleteNotification[T]()})
+		})
+	})
+	return e
+}
+
+func (e *Expectation[T]) record(m Message[T]) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messages = append(e.messages, m)
+}
+
+// Messages returns the notifications recorded so far.
+func (e *Expectation[T]) Messages() []Message[T] {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Message[T](nil), e.messages...)
+}
+
+// ToBe asserts, once the Scheduler is flushed, that the notifications match the marble diagram.
+// Values are looked up by their character in values, '#' expects err, or ErrMarble if err is nil.
+func (e *Expectation[T]) ToBe(marble string, values map[string]T, err error) {
+	t := e.s.t
+	t.Helper()
+	events, parseErr := parseMarble(marble, false)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	expected := messages(t, marble, events, values, err)
+	e.s.asserts = append(e.s.asserts, func() {
+		t.Helper()
+		actual := e.Messages()
+		if len(actual) == len(expected) {
+			equal := true
+			for i := range expected {
+				equal = equal && expected[i].equal(actual[i])
+			}
+			if equal {
+				return
+			}
+		}
+		t.Errorf("rxtest: %q doesn't match\n\texpected: %s\n\tactual:   %s", marble, format(expected), format(actual))
+	})
+}
+
+// SubscriptionExpectation asserts the subscription windows of a test Observable.
+type SubscriptionExpectation struct {
+	s      *Scheduler
+	source interface{ Subscriptions() []SubscriptionLog }
+}
+
+// ExpectSubscriptions asserts, once the Scheduler is flushed, the subscription windows of the test Observable.
+func (s *Scheduler) ExpectSubscriptions(source interface{ Subscriptions() []SubscriptionLog }) *SubscriptionExpectation {
+	return &SubscriptionExpectation{s, source}
+}
+
+// ToBe asserts that there is one subscription per marble diagram, such as "--^---!", in order of subscription.
+func (e *SubscriptionExpectation) ToBe(marbles ...string) {
+	t := e.s.t
+	t.Helper()
+	expected := make([]SubscriptionLog, 0, len(marbles))
+	for _, marble := range marbles {
+		log, err := parseSubscription(marble)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, log)
+	}
+	e.s.asserts = append(e.s.asserts, func() {
+		t.Helper()
+		actual := e.source.Subscriptions()
+		if !reflect.DeepEqual(expected, actual) && (len(expected) > 0 || len(actual) > 0) {
+			t.Errorf("rxtest: subscriptions don't match\n\texpected: %s\n\tactual:   %s", format(expected), format(actual))
+		}
+	})
+}
+
+func format[T fmt.Stringer](values []T) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = v.String()
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
@@ -0,0 +1,324 @@
+package rx
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Scheduler decides on which goroutine a unit of work runs, see ObserveOn and SubscribeOnScheduler.
+type Scheduler interface {
+	// Schedule runs work, either right away or later on another goroutine.
+	Schedule(work func())
+}
+
+type immediateScheduler struct{}
+
+// ImmediateScheduler returns the Scheduler which runs the work right away, on the goroutine scheduling it.
+func ImmediateScheduler() Scheduler {
+	return immediateScheduler{}
+}
+
+func (immediateScheduler) Schedule(work func()) {
+	work()
+}
+
+type goroutineScheduler struct{}
+
+// GoroutineScheduler returns the Scheduler which runs each unit of work on a new goroutine.
+func GoroutineScheduler() Scheduler {
+	return goroutineScheduler{}
+}
+
+func (goroutineScheduler) Schedule(work func()) {
+	go work()
+}
+
+// WorkerPool is a Scheduler running the work on a bounded number of goroutines.
+// The work scheduled while every worker is busy is queued, so Schedule never blocks.
+type WorkerPool struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	tasks  []func()
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewWorkerPool starts a WorkerPool of the given number of workers, it must be closed once it's not used anymore.
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers <= 0 {
+		panic(`NewWorkerPool required at least 1 worker`)
+	}
+	p := &WorkerPool{}
+	p.cond = sync.NewCond(&p.mu)
+	for range workers {
+		p.wg.Go(p.work)
+	}
+	return p
+}
+
+// Schedule queues work, it's run by the first available worker.
+// It panics if the WorkerPool is closed.
+func (p *WorkerPool) Schedule(work func()) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		panic(`rxgo: schedule on a closed WorkerPool`)
+	}
+	p.tasks = append(p.tasks, work)
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// Close stops the workers once the queued work is done, and waits for them.
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.tasks) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if len(p.tasks) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		work := p.tasks[0]
+		p.tasks[0] = nil
+		p.tasks = p.tasks[1:]
+		p.mu.Unlock()
+
+		work()
+	}
+}
+
+// bufferSizeOf returns the optional buffer size given to an operator, it's at least 1.
+func bufferSizeOf(bufferSize []int) int {
+	if len(bufferSize) > 0 && bufferSize[0] > 0 {
+		return bufferSize[0]
+	}
+	return 1
+}
+
+// ObserveOn re-emits the values of the source Observable on the Scheduler, in the same order:
+// the operators and the consumer after ObserveOn run on the goroutine chosen by the Scheduler,
+// while the source keeps running on the goroutine of the subscription.
+// A panic, or runtime.Goexit, of the consumer is raised again on the goroutine of the subscription.
+// The optional bufferSize (1 by default) is the number of values the source can emit ahead of the consumer.
+func ObserveOn[T any](s Scheduler, bufferSize ...int) OperatorFunc[T, T] {
+	size := bufferSizeOf(bufferSize)
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			var (
+				ch   = make(chan state[T], size)
+				done = make(chan struct{})
+
+				mu      sync.Mutex
+				running bool
+
+				// yield is never called once stopped, even by work which is still scheduled.
+				yieldMu sync.Mutex
+				stopped bool
+				// failed is set when the consumer panics, with failure, or calls runtime.Goexit, on the goroutine of the Scheduler.
+				failed  bool
+				failure any
+			)
+			// The failure of the consumer is raised again on the subscribing goroutine, as if it had run there.
+			defer func() {
+				yieldMu.Lock()
+				stopped = true
+				failed, failure := failed, failure
+				yieldMu.Unlock()
+				if !failed {
+					return
+				} else if failure != nil {
+					panic(failure)
+				} else {
+					runtime.Goexit()
+				}
+			}()
+
+			deliver := func(n state[T]) (ok bool) {
+				yieldMu.Lock()
+				defer yieldMu.Unlock()
+				if stopped {
+					return false
+				}
+				returned := false
+				defer func() {
+					if !returned {
+						failed, failure = true, recover()
+					}
+				}()
+				if n.err != nil {
+					var zero T
+					yield(zero, n.err)
+				} else if n.ok {
+					ok = yield(n.v, nil)
+				}
+				returned = true
+				return ok
+			}
+			// drain delivers the values queued so far, it's scheduled again once new values are queued.
+			drain := func() {
+				finished := false
+				// The consumer may call runtime.Goexit, which unwinds the goroutine of the Scheduler.
+				defer func() {
+					if !finished {
+						close(done)
+					}
+				}()
+				for {
+					select {
+					case n := <-ch:
+						if !deliver(n) {
+							finished = true
+							close(done)
+							return
+						}
+					default:
+						mu.Lock()
+						if len(ch) == 0 {
+							running = false
+							mu.Unlock()
+							finished = true
+							return
+						}
+						mu.Unlock()
+					}
+				}
+			}
+			push := func(n state[T]) bool {
+				select {
+				case <-ctx.Done():
+					return false
+				case <-done:
+					return false
+				case ch <- n:
+				}
+				mu.Lock()
+				if running {
+					mu.Unlock()
+					return true
+				}
+				running = true
+				mu.Unlock()
+				s.Schedule(drain)
+				return true
+			}
+
+			completed := true
+			for v, err := range input.SubscribeContext(ctx) {
+				if !push(state[T]{v, err, true}) || err != nil {
+					completed = false
+					break
+				}
+			}
+			if completed {
+				push(state[T]{})
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-done:
+			}
+		})
+	}
+}
+
+// SubscribeOnScheduler subscribes to the source Observable on the Scheduler, and hands its values over to the consumer in the same order.
+// The subscription is a single unit of work, it holds a worker of a WorkerPool until the source completes.
+// The optional bufferSize (1 by default) is the number of values the source can emit ahead of the consumer.
+func SubscribeOnScheduler[T any](s Scheduler, bufferSize ...int) OperatorFunc[T, T] {
+	size := bufferSizeOf(bufferSize)
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			// The immediate Scheduler subscribes on the current goroutine, no hand-off is needed.
+			if _, ok := s.(immediateScheduler); ok {
+				for v, err := range input.SubscribeContext(ctx) {
+					if !yield(v, err) {
+						return
+					}
+				}
+				return
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			var (
+				ch       = make(chan state[T], size)
+				finished = make(chan struct{})
+
+				mu        sync.Mutex
+				started   bool
+				abandoned bool
+			)
+			// Work which hasn't started yet is abandoned, otherwise it's awaited.
+			defer func() {
+				cancel()
+				mu.Lock()
+				abandoned = true
+				wait := started
+				mu.Unlock()
+				if wait {
+					<-finished
+				}
+			}()
+
+			s.Schedule(func() {
+				mu.Lock()
+				if abandoned {
+					mu.Unlock()
+					return
+				}
+				started = true
+				mu.Unlock()
+				defer close(finished)
+
+				for v, err := range input.SubscribeContext(ctx) {
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- state[T]{v, err, true}:
+					}
+					if err != nil {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+				case ch <- state[T]{}:
+				}
+			})
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-ch:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					} else {
+						if !yield(o.v, nil) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package rx
+
+import (
+	"context"
+
+	"github.com/si3nloong/rx/internal/errgroup"
+)
+
+// ToChannel subscribes to the Observable on a new goroutine and sends its values to the returned channel of the given buffer size.
+// The value channel is closed once the Observable terminates, the error channel then receives its error, if any, and is closed too.
+// The subscription holds its goroutine until the values are received, cancel ctx to stop it early.
+func ToChannel[T any](ctx context.Context, input Observable[T], buffer int) (<-chan T, <-chan error) {
+	values := make(chan T, max(buffer, 0))
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(values)
+
+		for v, err := range input.SubscribeContext(ctx) {
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			case values <- v:
+			}
+		}
+	}()
+	return values, errs
+}
+
+// ForEach subscribes to the Observable and calls fn for each value, it blocks until the Observable terminates.
+// It returns the error of the Observable, or the first error returned by fn, which stops the subscription.
+// The optional concurrent (1 by default) is the number of values fn can process at the same time, on as many goroutines.
+func ForEach[T any](ctx context.Context, input Observable[T], fn func(v T) error, concurrent ...int) error {
+	if len(concurrent) == 0 || concurrent[0] <= 1 {
+		for v, err := range input.SubscribeContext(ctx) {
+			if err != nil {
+				return err
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrent[0])
+	for v, err := range input.SubscribeContext(ctx) {
+		if err != nil {
+			// The subscription is cancelled by the first error of fn.
+			if failure := g.Wait(); failure != nil {
+				return failure
+			}
+			return err
+		}
+		g.Go(func() error {
+			return fn(v)
+		})
+	}
+	return g.Wait()
+}
+
+// FirstValueFrom subscribes to the Observable and returns its first value, the subscription stops right after.
+// It returns ErrEmpty if the Observable completes without emitting any value.
+func FirstValueFrom[T any](ctx context.Context, input Observable[T]) (T, error) {
+	for v, err := range input.SubscribeContext(ctx) {
+		return v, err
+	}
+	var zero T
+	return zero, ErrEmpty
+}
+
+// LastValueFrom subscribes to the Observable and returns its last value once it completes.
+// It returns ErrEmpty if the Observable completes without emitting any value.
+func LastValueFrom[T any](ctx context.Context, input Observable[T]) (T, error) {
+	var (
+		last T
+		ok   bool
+	)
+	for v, err := range input.SubscribeContext(ctx) {
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		last, ok = v, true
+	}
+	if !ok {
+		return last, ErrEmpty
+	}
+	return last, nil
+}
@@ -2,16 +2,15 @@ package rx
 
 import (
 	"context"
-	"iter"
 	"sync"
 )
 
 // Skip returns an Observable that skips the first count items emitted by the source Observable.
 func Skip[T any](count uint) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var skipCount uint
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -33,9 +32,9 @@ func Skip[T any](count uint) OperatorFunc[T, T] {
 // SkipLast skips a specified number of values before the completion of an observable.
 func SkipLast[T any](count uint) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			result := make([]T, 0, count)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -61,9 +60,9 @@ func SkipLast[T any](count uint) OperatorFunc[T, T] {
 // SkipWhile returns an Observable that skips all items emitted by the source Observable as long as a specified condition holds true, but emits all further source items as soon as the condition becomes false.
 func SkipWhile[T any](fn func(T, int) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -84,35 +83,25 @@ func SkipWhile[T any](fn func(T, int) bool) OperatorFunc[T, T] {
 // SkipUntil returns an Observable that skips items emitted by the source Observable until a second Observable emits an item.
 func SkipUntil[T, U any](notifier Observable[U]) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
 			var wg sync.WaitGroup
-			ctx, cancel := context.WithCancel(context.Background())
+			defer wg.Wait()
 			defer cancel()
 
-			ch := make(chan state[U], 1)
-			defer close(ch)
-
-			wg.Go(func() {
-				next, stop := iter.Pull2(notifier.Subscribe())
-				defer stop()
-				// Internally, the skipUntil operator subscribes to the passed in notifier ObservableInput (which gets converted to an Observable)
-				// in order to recognize the emission of its first value.
-				v, err, ok := next()
-				// When notifier emits next, the operator unsubscribes from it and starts emitting the values of the source observable until it completes or errors.
-				select {
-				case <-ctx.Done():
-				case ch <- state[U]{v, err, ok}:
-				}
-			})
-
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
+			// Internally, the skipUntil operator subscribes to the passed in notifier ObservableInput (which gets converted to an Observable)
+			// in order to recognize the emission of its first value.
+			notifierCtx, stopNotifier := context.WithCancel(ctx)
+			defer stopNotifier()
+			notified := subscribeAsync(notifierCtx, &wg, notifier)
+			ch := subscribeAsync(ctx, &wg, input)
 
 			var allowedEmit bool
-		loop:
 			for {
 				select {
-				case o := <-ch:
+				case <-ctx.Done():
+					return
+				case o := <-notified:
 					// It will never let the source observable emit any values if the notifier completes or throws an error without emitting a value before.
 					if o.err != nil {
 						var zero T
@@ -121,27 +110,25 @@ func SkipUntil[T, U any](notifier Observable[U]) OperatorFunc[T, T] {
 					} else if !o.ok {
 						return
 					} else {
+						// When notifier emits next, the operator unsubscribes from it and starts emitting the values of the source observable until it completes or errors.
 						allowedEmit = true
+						stopNotifier()
+						notified = nil
 					}
-				default:
-					v, err, ok := next()
-					if err != nil {
-						cancel()
+				case o := <-ch:
+					if o.err != nil {
 						var zero T
-						yield(zero, err)
-						break loop
-					} else if !ok {
-						cancel()
-						break loop
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
 					} else if allowedEmit {
-						if !yield(v, nil) {
+						if !yield(o.v, nil) {
 							return
 						}
 					}
 				}
 			}
-
-			wg.Wait()
 		})
 	}
 }
@@ -0,0 +1,314 @@
+package rx
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Subject is a special type of Observable that allows values to be multicasted to many subscribers.
+// Unlike an ObservableFunc, a Subject is hot: values are produced by calling Next, Error and Complete,
+// and every active subscriber receives them.
+//
+// Subjects are safe for concurrent use, each subscriber has its own buffer so a slow subscriber never blocks publishers.
+type Subject[T any] interface {
+	Observable[T]
+	// Next emits a value to every subscriber.
+	Next(v T)
+	// Error emits an error to every subscriber and terminates the Subject.
+	Error(err error)
+	// Complete notifies every subscriber of the completion and terminates the Subject.
+	Complete()
+	// Observed reports whether the Subject has at least one active subscriber.
+	Observed() bool
+}
+
+type subjectObserver[T any] struct {
+	queue  []state[T]
+	signal chan struct{}
+}
+
+func (o *subjectObserver[T]) push(n state[T]) {
+	o.queue = append(o.queue, n)
+	select {
+	case o.signal <- struct{}{}:
+	default:
+	}
+}
+
+type subject[T any] struct {
+	mu        sync.Mutex
+	observers []*subjectObserver[T]
+	stopped   bool
+	err       error
+
+	// replay is invoked while holding the lock to preload the notifications of a new subscriber.
+	replay func(o *subjectObserver[T])
+}
+
+// NewSubject creates a Subject which only emits to a subscriber the values published after its subscription.
+func NewSubject[T any]() Subject[T] {
+	return &subject[T]{}
+}
+
+// Next emits a value to every subscriber.
+func (s *subject[T]) Next(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.broadcast(state[T]{v: v, ok: true})
+}
+
+// Error emits an error to every subscriber and terminates the Subject.
+func (s *subject[T]) Error(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped, s.err = true, err
+	s.broadcast(state[T]{err: err})
+	s.observers = nil
+}
+
+// Complete notifies every subscriber of the completion and terminates the Subject.
+func (s *subject[T]) Complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	s.broadcast(state[T]{})
+	s.observers = nil
+}
+
+// Observed reports whether the Subject has at least one active subscriber.
+func (s *subject[T]) Observed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.observers) > 0
+}
+
+// Subscribe returns an iterator that yields the values published to the Subject.
+func (s *subject[T]) Subscribe() iter.Seq2[T, error] {
+	return s.SubscribeContext(context.Background())
+}
+
+// SubscribeContext returns an iterator that yields the values published to the Subject until ctx is done.
+func (s *subject[T]) SubscribeContext(ctx context.Context) iter.Seq2[T, error] {
+	return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+		s.observe(ctx, yield, nil)
+	}).SubscribeContext(ctx)
+}
+
+// SubscribeOn subscribes to the Subject and executes the provided callbacks for each event.
+func (s *subject[T]) SubscribeOn(onNext func(v T), onError func(err error), onComplete func()) {
+	subscribeOn(s.Subscribe(), onNext, onError, onComplete)
+}
+
+func (s *subject[T]) broadcast(n state[T]) {
+	for _, o := range s.observers {
+		o.push(n)
+	}
+}
+
+func (s *subject[T]) add() *subjectObserver[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := &subjectObserver[T]{signal: make(chan struct{}, 1)}
+	if s.replay != nil {
+		s.replay(o)
+	}
+	if s.stopped {
+		o.push(state[T]{err: s.err})
+	} else {
+		s.observers = append(s.observers, o)
+	}
+	return o
+}
+
+func (s *subject[T]) remove(o *subjectObserver[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = slices.DeleteFunc(s.observers, func(v *subjectObserver[T]) bool {
+		return v == o
+	})
+}
+
+// hotObservable is implemented by every Subject of this package,
+// it allows an operator to be notified once a subscriber is registered.
+type hotObservable[T any] interface {
+	observe(ctx context.Context, yield func(T, error) bool, subscribed func())
+}
+
+func (s *subject[T]) observe(ctx context.Context, yield func(T, error) bool, subscribed func()) {
+	o := s.add()
+	defer s.remove(o)
+
+	if subscribed != nil {
+		subscribed()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.signal:
+		}
+
+		s.mu.Lock()
+		queue := o.queue
+		o.queue = nil
+		s.mu.Unlock()
+
+		for _, n := range queue {
+			if n.err != nil {
+				var zero T
+				yield(zero, n.err)
+				return
+			} else if !n.ok {
+				return
+			} else if !yield(n.v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// BehaviorSubject is a Subject that requires an initial value and emits its current value to new subscribers.
+type BehaviorSubject[T any] struct {
+	subject[T]
+	value T
+}
+
+// NewBehaviorSubject creates a BehaviorSubject with the given initial value.
+func NewBehaviorSubject[T any](value T) *BehaviorSubject[T] {
+	s := &BehaviorSubject[T]{value: value}
+	s.replay = func(o *subjectObserver[T]) {
+		if !s.stopped {
+			o.push(state[T]{v: s.value, ok: true})
+		}
+	}
+	return s
+}
+
+// Next stores the value as the current value and emits it to every subscriber.
+func (s *BehaviorSubject[T]) Next(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.value = v
+	s.broadcast(state[T]{v: v, ok: true})
+}
+
+// Value returns the current value of the BehaviorSubject.
+func (s *BehaviorSubject[T]) Value() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+type replayValue[T any] struct {
+	time  time.Time
+	value T
+}
+
+// ReplaySubject is a Subject that records the values published to it and replays them to new subscribers.
+type ReplaySubject[T any] struct {
+	subject[T]
+	clock      Clock
+	bufferSize int
+	windowTime time.Duration
+	buffer     []replayValue[T]
+}
+
+// NewReplaySubject creates a ReplaySubject which replays at most bufferSize values, no older than windowTime.
+// A bufferSize or a windowTime lower or equal to zero means the buffer isn't bounded by it.
+// The optional clock is used to measure the windowTime, it defaults to RealClock.
+func NewReplaySubject[T any](bufferSize int, windowTime time.Duration, clock ...Clock) *ReplaySubject[T] {
+	s := &ReplaySubject[T]{clock: clockOf(context.Background(), clock), bufferSize: bufferSize, windowTime: windowTime}
+	s.replay = func(o *subjectObserver[T]) {
+		s.trim()
+		for _, v := range s.buffer {
+			o.push(state[T]{v: v.value, ok: true})
+		}
+	}
+	return s
+}
+
+// Next records the value and emits it to every subscriber.
+func (s *ReplaySubject[T]) Next(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.buffer = append(s.buffer, replayValue[T]{s.clock.Now(), v})
+	s.trim()
+	s.broadcast(state[T]{v: v, ok: true})
+}
+
+func (s *ReplaySubject[T]) trim() {
+	if s.bufferSize > 0 && len(s.buffer) > s.bufferSize {
+		s.buffer = slices.Delete(s.buffer, 0, len(s.buffer)-s.bufferSize)
+	}
+	if s.windowTime > 0 {
+		deadline := s.clock.Now().Add(-s.windowTime)
+		i := 0
+		for i < len(s.buffer) && s.buffer[i].time.Before(deadline) {
+			i++
+		}
+		s.buffer = slices.Delete(s.buffer, 0, i)
+	}
+}
+
+// AsyncSubject is a Subject that only emits the last value published to it, and only once it completes.
+type AsyncSubject[T any] struct {
+	subject[T]
+	value    T
+	hasValue bool
+}
+
+// NewAsyncSubject creates an AsyncSubject.
+func NewAsyncSubject[T any]() *AsyncSubject[T] {
+	s := &AsyncSubject[T]{}
+	s.replay = func(o *subjectObserver[T]) {
+		if s.stopped && s.err == nil && s.hasValue {
+			o.push(state[T]{v: s.value, ok: true})
+		}
+	}
+	return s
+}
+
+// Next records the value, it is only emitted once the AsyncSubject completes.
+func (s *AsyncSubject[T]) Next(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.value, s.hasValue = v, true
+}
+
+// Complete emits the last value to every subscriber then notifies them of the completion.
+func (s *AsyncSubject[T]) Complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	if s.hasValue {
+		s.broadcast(state[T]{v: s.value, ok: true})
+	}
+	s.broadcast(state[T]{})
+	s.observers = nil
+}
@@ -0,0 +1,138 @@
+package rx
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Subscription is the handle of a subscription running on its own goroutine, see SubscribeAsync.
+type Subscription interface {
+	// Unsubscribe stops the subscription, it doesn't wait for it to end, use Done for that.
+	Unsubscribe()
+	// Done returns a channel that's closed once the subscription ends.
+	Done() <-chan struct{}
+	// Err returns nil until Done is closed. Then it returns the error of the Observable,
+	// the error of the context if it has been unsubscribed, or nil if the Observable completed.
+	Err() error
+}
+
+type subscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// SubscribeAsync subscribes to the Observable on a new goroutine and executes the provided callbacks for each event, it doesn't block.
+// The subscription ends when the Observable terminates, ctx is done or the returned Subscription is unsubscribed;
+// onError isn't called in the last two cases. A nil callback is ignored.
+func SubscribeAsync[T any](ctx context.Context, input Observable[T], onNext func(v T), onError func(err error), onComplete func()) Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &subscription{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer cancel()
+
+		for v, err := range input.SubscribeContext(ctx) {
+			if err != nil {
+				s.err = err
+				if ctx.Err() == nil && onError != nil {
+					onError(err)
+				}
+				return
+			} else if onNext != nil {
+				onNext(v)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			s.err = err
+		} else if onComplete != nil {
+			onComplete()
+		}
+	}()
+	return s
+}
+
+func (s *subscription) Unsubscribe() {
+	s.cancel()
+}
+
+func (s *subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// CompositeSubscription groups Subscriptions, so they're unsubscribed all at once.
+// The zero value is ready to use.
+type CompositeSubscription struct {
+	mu           sync.Mutex
+	subs         []Subscription
+	unsubscribed bool
+}
+
+// Add adds the Subscriptions to the group.
+// They're unsubscribed right away if the CompositeSubscription has been unsubscribed already.
+func (c *CompositeSubscription) Add(subs ...Subscription) {
+	c.mu.Lock()
+	c.subs = append(c.subs, subs...)
+	unsubscribed := c.unsubscribed
+	c.mu.Unlock()
+
+	if unsubscribed {
+		for _, s := range subs {
+			s.Unsubscribe()
+		}
+	}
+}
+
+// Remove removes the Subscription from the group without unsubscribing it.
+func (c *CompositeSubscription) Remove(sub Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subs = slices.DeleteFunc(c.subs, func(v Subscription) bool {
+		return v == sub
+	})
+}
+
+// Len returns the number of Subscriptions of the group.
+func (c *CompositeSubscription) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subs)
+}
+
+// Unsubscribe unsubscribes every Subscription of the group, and the ones added later.
+func (c *CompositeSubscription) Unsubscribe() {
+	c.mu.Lock()
+	subs := slices.Clone(c.subs)
+	c.unsubscribed = true
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+// Wait blocks until every Subscription of the group has ended, or ctx is done.
+func (c *CompositeSubscription) Wait(ctx context.Context) error {
+	c.mu.Lock()
+	subs := slices.Clone(c.subs)
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.Done():
+		}
+	}
+	return nil
+}
@@ -2,26 +2,30 @@ package rx
 
 import (
 	"context"
-	"iter"
 	"sync"
 )
 
 // Take emits only the first count values emitted by the source Observable.
 func Take[T any](count uint) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			if count == 0 {
+				return
+			}
+
 			var takeCount uint
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
 					return
 				} else {
-					takeCount++
-					if takeCount > count {
+					if !yield(v, nil) {
 						return
 					}
-					if !yield(v, nil) {
+					takeCount++
+					// Complete right away, rather than waiting for the next value of the source.
+					if takeCount >= count {
 						return
 					}
 				}
@@ -34,9 +38,9 @@ func Take[T any](count uint) OperatorFunc[T, T] {
 // TakeLast waits for the source to complete, then emits the last N values from the source, as specified by the count argument.
 func TakeLast[T any](count uint) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			result := make([]T, 0, count)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -62,9 +66,9 @@ func TakeLast[T any](count uint) OperatorFunc[T, T] {
 // TakeWhile emits values emitted by the source Observable so long as each value satisfies the given predicate, and then completes as soon as this predicate is not satisfied.
 func TakeWhile[T any](fn func(T, int) bool) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero T
 					yield(zero, err)
@@ -86,60 +90,44 @@ func TakeWhile[T any](fn func(T, int) bool) OperatorFunc[T, T] {
 // TakeUntil emits the values emitted by the source Observable until a notifier Observable emits a value.
 func TakeUntil[T, U any](notifier Observable[U]) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
 			var wg sync.WaitGroup
-			ctx, cancel := context.WithCancel(context.Background())
+			defer wg.Wait()
 			defer cancel()
 
-			ch := make(chan state[U], 1)
-			defer close(ch)
+			notified := subscribeAsync(ctx, &wg, notifier)
+			ch := subscribeAsync(ctx, &wg, input)
 
-			wg.Go(func() {
-				next, stop := iter.Pull2(notifier.Subscribe())
-				defer stop()
-
-				v, err, ok := next()
-				select {
-				case <-ctx.Done():
-				case ch <- state[U]{v, err, ok}:
-				}
-			})
-
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
-		loop:
 			for {
 				select {
-				case o := <-ch:
+				case <-ctx.Done():
+					return
+				case o := <-notified:
 					if o.err != nil {
 						var zero T
 						yield(zero, o.err)
 						return
 					} else if !o.ok {
 						// If the notifier doesn't emit any value and completes then takeUntil will pass all values.
+						notified = nil
 						continue
 					}
 					return
-				default:
-					v, err, ok := next()
-					if err != nil {
-						cancel()
+				case o := <-ch:
+					if o.err != nil {
 						var zero T
-						yield(zero, err)
-						break loop
-					} else if !ok {
-						cancel()
-						break loop
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
 					} else {
-						if !yield(v, nil) {
-							cancel()
-							break loop
+						if !yield(o.v, nil) {
+							return
 						}
 					}
 				}
 			}
-
-			wg.Wait()
 		})
 	}
 }
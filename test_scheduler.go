@@ -0,0 +1,212 @@
+package rx
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TestScheduler is a virtual Clock, its time only moves forward when AdvanceBy, AdvanceTo or Flush is called.
+// It makes the time-based operators deterministic and fast to test.
+// It's also a Scheduler, the scheduled work runs on the goroutine moving the virtual time forward.
+//
+// Before firing a timer, the TestScheduler calls its settle function, which must wait until the goroutines of the pipeline
+// have processed the previous timers, whatever their number. synctest.Wait does so for the tests running in a synctest bubble.
+type TestScheduler struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers []*virtualTimer
+	settle func()
+}
+
+// NewTestScheduler creates a TestScheduler, its virtual time starts at the Unix epoch.
+// The optional settle is called before each timer fires, and before AdvanceBy and AdvanceTo return,
+// typically synctest.Wait. Without it, the timers fire one after the other without waiting for their effects.
+func NewTestScheduler(settle ...func()) *TestScheduler {
+	s := &TestScheduler{now: time.Unix(0, 0).UTC(), settle: func() {}}
+	if len(settle) > 0 && settle[0] != nil {
+		s.settle = settle[0]
+	}
+	return s
+}
+
+// Now returns the virtual time.
+func (s *TestScheduler) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// NewTimer creates a ClockTimer firing once the virtual time reaches now + d.
+func (s *TestScheduler) NewTimer(d time.Duration) ClockTimer {
+	t := &virtualTimer{s: s, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker creates a ClockTicker firing each time the virtual time moves forward by d.
+func (s *TestScheduler) NewTicker(d time.Duration) ClockTicker {
+	if d <= 0 {
+		panic(`rxgo: non-positive interval for NewTicker`)
+	}
+	t := &virtualTimer{s: s, c: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return virtualTicker{t}
+}
+
+// Schedule runs work at the current virtual time, the next time AdvanceBy, AdvanceTo or Flush is called,
+// on the goroutine calling it.
+func (s *TestScheduler) Schedule(work func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &virtualTimer{s: s, work: work}
+	t.schedule(s.now)
+}
+
+// AdvanceBy moves the virtual time forward by d, firing every timer due in the meantime in chronological order.
+func (s *TestScheduler) AdvanceBy(d time.Duration) {
+	s.AdvanceTo(s.Now().Add(d))
+}
+
+// AdvanceTo moves the virtual time forward to t, firing every timer due in the meantime in chronological order.
+func (s *TestScheduler) AdvanceTo(t time.Time) {
+	for {
+		s.settle()
+
+		s.mu.Lock()
+		if len(s.timers) == 0 || s.timers[0].when.After(t) {
+			if s.now.Before(t) {
+				s.now = t
+			}
+			s.mu.Unlock()
+			s.settle()
+			return
+		}
+		work := s.fire()
+		s.mu.Unlock()
+		if work != nil {
+			work()
+		}
+	}
+}
+
+// Flush fires every pending timer in chronological order, until none is left.
+// It never returns if a ClockTicker is still running, use AdvanceBy or AdvanceTo instead.
+func (s *TestScheduler) Flush() {
+	for {
+		s.settle()
+
+		s.mu.Lock()
+		if len(s.timers) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		work := s.fire()
+		s.mu.Unlock()
+		if work != nil {
+			work()
+		}
+	}
+}
+
+// fire fires the earliest timer, it must be called while holding the lock.
+// It returns the scheduled work of the timer, which must be run once the lock is released.
+func (s *TestScheduler) fire() func() {
+	t := s.timers[0]
+	s.timers = s.timers[1:]
+	if t.when.After(s.now) {
+		s.now = t.when
+	}
+	t.active = false
+	if t.work != nil {
+		return t.work
+	}
+	if t.period > 0 {
+		t.schedule(t.when.Add(t.period))
+	}
+	select {
+	case t.c <- s.now:
+	default:
+	}
+	return nil
+}
+
+type virtualTimer struct {
+	s      *TestScheduler
+	c      chan time.Time
+	when   time.Time
+	period time.Duration
+	seq    uint64
+	active bool
+	work   func()
+}
+
+type virtualTicker struct {
+	*virtualTimer
+}
+
+func (t virtualTicker) Stop() {
+	t.virtualTimer.Stop()
+}
+
+func (t *virtualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	return t.stop()
+}
+
+func (t *virtualTimer) Reset(d time.Duration) bool {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	active := t.stop()
+	if t.period == 0 && d <= 0 {
+		// Like time.Timer, a timer without duration fires right away.
+		select {
+		case t.c <- t.s.now:
+		default:
+		}
+		return active
+	}
+	if t.period > 0 && d > 0 {
+		t.period = d
+	}
+	t.schedule(t.s.now.Add(d))
+	return active
+}
+
+// stop must be called while holding the lock of the TestScheduler.
+// Like time.Timer, no stale value is received once stopped.
+func (t *virtualTimer) stop() bool {
+	select {
+	case <-t.c:
+	default:
+	}
+	if !t.active {
+		return false
+	}
+	t.active = false
+	t.s.timers = slices.DeleteFunc(t.s.timers, func(v *virtualTimer) bool {
+		return v == t
+	})
+	return true
+}
+
+// schedule must be called while holding the lock of the TestScheduler.
+func (t *virtualTimer) schedule(when time.Time) {
+	t.s.seq++
+	t.when, t.seq, t.active = when, t.s.seq, true
+	i, _ := slices.BinarySearchFunc(t.s.timers, t, func(a, b *virtualTimer) int {
+		if c := a.when.Compare(b.when); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	t.s.timers = slices.Insert(t.s.timers, i, t)
+}
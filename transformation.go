@@ -2,58 +2,53 @@ package rx
 
 import (
 	"context"
-	"iter"
 	"sync"
 	"time"
+
+	"github.com/si3nloong/rx/internal/errgroup"
 )
 
 // Buffer buffers the source Observable values until closingNotifier emits.
 func Buffer[T any, I any](closingNotifier Observable[I]) OperatorFunc[T, []T] {
 	return func(input Observable[T]) Observable[[]T] {
-		return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
-			var buffer = make([]T, 0)
-			var rw sync.RWMutex
-
-			ctx, cancel := context.WithCancel(context.Background())
+		return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
 			defer cancel()
 
-			go func() {
-				next, stop := iter.Pull2(input.Subscribe())
-				defer stop()
+			ch := subscribeAsync(ctx, &wg, input)
+			notifier := subscribeAsync(ctx, &wg, closingNotifier)
 
-				for {
-					select {
-					case <-ctx.Done():
+			buffer := make([]T, 0)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-ch:
+					if o.err != nil {
+						yield(nil, o.err)
 						return
-					default:
-						v, err, ok := next()
-						if err != nil {
-							yield(nil, err)
-							return
-						} else if !ok {
-							return
-						} else {
-							rw.Lock()
-							buffer = append(buffer, v)
-							rw.Unlock()
-						}
+					} else if !o.ok {
+						// Once the source completes, the remaining values are emitted as the last buffer.
+						yield(buffer, nil)
+						return
+					} else {
+						buffer = append(buffer, o.v)
 					}
-				}
-			}()
-
-			for _, err := range closingNotifier.Subscribe() {
-				if err != nil {
-					cancel()
-					yield(nil, err)
-					return
-				} else {
-					rw.Lock()
-					if !yield(buffer, nil) {
-						rw.Unlock()
+				case o := <-notifier:
+					if o.err != nil {
+						yield(nil, o.err)
 						return
+					} else if !o.ok {
+						// The completion of closingNotifier doesn't complete the resulting Observable.
+						notifier = nil
+					} else {
+						if !yield(buffer, nil) {
+							return
+						}
+						buffer = make([]T, 0)
 					}
-					buffer = make([]T, 0)
-					rw.Unlock()
 				}
 			}
 		})
@@ -61,60 +56,110 @@ func Buffer[T any, I any](closingNotifier Observable[I]) OperatorFunc[T, []T] {
 }
 
 // BufferCount buffers the source Observable values into a slice of a specific size.
-func BufferCount[T any](count uint) OperatorFunc[T, []T] {
+// A new buffer is started every startBufferEvery values (bufferSize by default), so the buffers overlap if it's lower than bufferSize.
+// A zero bufferSize emits each value in its own buffer.
+func BufferCount[T any](bufferSize uint, startBufferEvery ...uint) OperatorFunc[T, []T] {
+	bufferSize = max(bufferSize, 1)
+	every := bufferSize
+	if len(startBufferEvery) > 0 && startBufferEvery[0] > 0 {
+		every = startBufferEvery[0]
+	}
 	return func(input Observable[T]) Observable[[]T] {
-		return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
-			buffer := make([]T, 0, count)
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+			var (
+				buffers [][]T
+				count   uint
+			)
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(nil, err)
 					return
 				} else {
-					buffer = append(buffer, v)
-					if (uint)(len(buffer)) >= count {
-						if !yield(buffer, nil) {
+					if count%every == 0 {
+						buffers = append(buffers, make([]T, 0, bufferSize))
+					}
+					count++
+					for i := range buffers {
+						buffers[i] = append(buffers[i], v)
+					}
+					// Only the oldest buffer can be full.
+					if len(buffers) > 0 && (uint)(len(buffers[0])) >= bufferSize {
+						if !yield(buffers[0], nil) {
 							return
 						}
-						buffer = make([]T, 0, count)
+						buffers = buffers[1:]
 					}
 				}
 			}
-			if len(buffer) > 0 {
+			for _, buffer := range buffers {
 				if !yield(buffer, nil) {
 					return
 				}
-				clear(buffer)
 			}
 		})
 	}
 }
 
-// Buffers the source Observable values for a specific time period.
-func BufferTime[T any](duration time.Duration) OperatorFunc[T, []T] {
+// BufferTime buffers the source Observable values for a specific time period.
+// Each buffer is emitted after bufferTimeSpan, a new one is started as soon as the previous one is emitted unless WindowTimeOptions says otherwise.
+// Once the source completes, the non-empty buffers which are still open are emitted.
+func BufferTime[T any](bufferTimeSpan time.Duration, opts ...WindowTimeOptions) OperatorFunc[T, []T] {
+	return func(input Observable[T]) Observable[[]T] {
+		return buffers(true, routeTime(input, bufferTimeSpan, opts))
+	}
+}
+
+// BufferTimeOrCount buffers the source Observable values until the buffer contains maxBufferSize values,
+// or bufferTimeSpan has elapsed since the first value of the buffer, whichever happens first.
+// Unlike BufferTime, an empty buffer is never emitted.
+// The optional clock overrides the Clock of the pipeline.
+func BufferTimeOrCount[T any](bufferTimeSpan time.Duration, maxBufferSize int, clock ...Clock) OperatorFunc[T, []T] {
 	return func(input Observable[T]) Observable[[]T] {
-		return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
+		return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
 
-			timer := time.NewTicker(duration)
+			timer := clockOf(ctx, clock).NewTimer(bufferTimeSpan)
+			timer.Stop()
 			defer timer.Stop()
 
 			buffer := make([]T, 0)
 			for {
 				select {
-				case <-timer.C:
+				case <-ctx.Done():
+					return
+				case <-timer.C():
+					if len(buffer) == 0 {
+						continue
+					}
 					if !yield(buffer, nil) {
 						return
 					}
 					buffer = make([]T, 0)
-				default:
-					v, err, ok := next()
-					if err != nil {
+				case o := <-ch:
+					if o.err != nil {
+						yield(nil, o.err)
 						return
-					} else if !ok {
+					} else if !o.ok {
+						if len(buffer) > 0 {
+							yield(buffer, nil)
+						}
 						return
 					} else {
-						buffer = append(buffer, v)
+						buffer = append(buffer, o.v)
+						if maxBufferSize > 0 && len(buffer) >= maxBufferSize {
+							timer.Stop()
+							if !yield(buffer, nil) {
+								return
+							}
+							buffer = make([]T, 0)
+						} else if len(buffer) == 1 {
+							timer.Reset(bufferTimeSpan)
+						}
 					}
 				}
 			}
@@ -122,12 +167,30 @@ func BufferTime[T any](duration time.Duration) OperatorFunc[T, []T] {
 	}
 }
 
+// BufferToggle buffers the source Observable values starting each time openings emits,
+// and emits the buffer when the Observable returned by closingSelector emits.
+// Once the source completes, the buffers which are still open are emitted.
+func BufferToggle[T, O, C any](openings Observable[O], closingSelector func(v O) Observable[C]) OperatorFunc[T, []T] {
+	return func(input Observable[T]) Observable[[]T] {
+		return buffers(false, routeToggle(input, openings, closingSelector))
+	}
+}
+
+// BufferWhen buffers the source Observable values, using closingSelector to know when to emit the buffer.
+// A new buffer is started immediately and each time the Observable returned by closingSelector emits.
+// Once the source completes, the current buffer is emitted.
+func BufferWhen[T, C any](closingSelector func() Observable[C]) OperatorFunc[T, []T] {
+	return func(input Observable[T]) Observable[[]T] {
+		return buffers(false, routeWhen(input, closingSelector))
+	}
+}
+
 // Map applies a given project function to each value emitted by the source Observable, and emits the resulting values as an Observable.
 func Map[I, O any](fn func(v I, index int) O) OperatorFunc[I, O] {
 	return func(input Observable[I]) Observable[O] {
-		return (ObservableFunc[O])(func(yield func(O, error) bool) {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var o O
 					yield(o, err)
@@ -146,9 +209,9 @@ func Map[I, O any](fn func(v I, index int) O) OperatorFunc[I, O] {
 // MapErr is similar to Map but deals with error.
 func MapErr[I, O any](fn func(v I, index int) (O, error)) OperatorFunc[I, O] {
 	return func(input Observable[I]) Observable[O] {
-		return (ObservableFunc[O])(func(yield func(O, error) bool) {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero O
 					yield(zero, err)
@@ -169,18 +232,157 @@ func MapErr[I, O any](fn func(v I, index int) (O, error)) OperatorFunc[I, O] {
 	}
 }
 
+// ParallelOptions customises ParallelMap and ParallelMapErr.
+type ParallelOptions struct {
+	// Unordered emits the results as soon as they are ready, instead of in the order of the source.
+	Unordered bool
+	// MaxInFlight bounds the number of values being processed or waiting to be emitted, it defaults to twice the number of workers.
+	// The source is paused once the limit is reached.
+	MaxInFlight int
+}
+
+// ParallelMap is similar to Map but applies fn on the given number of goroutines.
+// The results are emitted in the order of the source, unless ParallelOptions.Unordered is set.
+func ParallelMap[I, O any](workers int, fn func(v I, index int) O, opts ...ParallelOptions) OperatorFunc[I, O] {
+	return ParallelMapErr(workers, func(v I, index int) (O, error) {
+		return fn(v, index), nil
+	}, opts...)
+}
+
+// ParallelMapErr is similar to ParallelMap but deals with error.
+// The first error returned by fn is emitted as soon as it occurs, and the pending values are abandoned.
+// The error of the source is emitted after the results of the values it emitted before.
+func ParallelMapErr[I, O any](workers int, fn func(v I, index int) (O, error), opts ...ParallelOptions) OperatorFunc[I, O] {
+	if workers <= 0 {
+		panic(`ParallelMap required at least 1 worker`)
+	}
+	var opt ParallelOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MaxInFlight <= 0 {
+		opt.MaxInFlight = workers * 2
+	}
+	return func(input Observable[I]) Observable[O] {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			type job struct {
+				idx int
+				v   I
+			}
+			var (
+				jobs     = make(chan job)
+				results  = make(chan goState[O])
+				inFlight = make(chan struct{}, opt.MaxInFlight)
+				// end receives the number of values emitted by the source once it completes, or its error.
+				end = make(chan goState[O], 1)
+			)
+
+			wg.Go(func() {
+				defer close(jobs)
+				var i int
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						end <- goState[O]{idx: i, err: err}
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case inFlight <- struct{}{}:
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case jobs <- job{i, v}:
+					}
+					i++
+				}
+				end <- goState[O]{idx: i}
+			})
+			for range workers {
+				wg.Go(func() {
+					for j := range jobs {
+						v, err := fn(j.v, j.idx)
+						select {
+						case <-ctx.Done():
+							return
+						case results <- goState[O]{j.idx, v, err, true}:
+						}
+					}
+				})
+			}
+
+			var (
+				next    int
+				total   = -1
+				pending = make(map[int]O)
+				failure error
+			)
+			for {
+				if total >= 0 && next >= total {
+					if failure != nil {
+						var zero O
+						yield(zero, failure)
+					}
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-end:
+					// The error of the source is emitted once the values it has already emitted are.
+					total, failure = o.idx, o.err
+				case o := <-results:
+					if o.err != nil {
+						var zero O
+						yield(zero, o.err)
+						return
+					}
+					if opt.Unordered {
+						next++
+						if !yield(o.v, nil) {
+							return
+						}
+						<-inFlight
+						continue
+					}
+
+					pending[o.idx] = o.v
+					for {
+						v, ok := pending[next]
+						if !ok {
+							break
+						}
+						delete(pending, next)
+						next++
+						if !yield(v, nil) {
+							return
+						}
+						<-inFlight
+					}
+				}
+			}
+		})
+	}
+}
+
 // ConcatMap projects each source value to an Observable which is merged in the output Observable, in a serialized fashion waiting for each one to complete before merging the next.
 func ConcatMap[I, O any](project func(v I, index int) Observable[O]) OperatorFunc[I, O] {
 	return func(input Observable[I]) Observable[O] {
-		return (ObservableFunc[O])(func(yield func(O, error) bool) {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
 			var i int
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero O
 					yield(zero, err)
 					return
 				}
-				for v2, err2 := range project(v, i).Subscribe() {
+				for v2, err2 := range project(v, i).SubscribeContext(ctx) {
 					if err2 != nil {
 						var zero O
 						yield(zero, err2)
@@ -198,89 +400,325 @@ func ConcatMap[I, O any](project func(v I, index int) Observable[O]) OperatorFun
 }
 
 // SwitchMap projects each source value to an Observable which is merged in the output Observable, emitting values only from the most recently projected Observable.
+// The projected Observable in flight is cancelled as soon as the source emits a new value.
 func SwitchMap[I, O any](fn func(v I, index int) Observable[O]) OperatorFunc[I, O] {
 	return func(input Observable[I]) Observable[O] {
-		return (ObservableFunc[O])(func(yield func(O, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
+		return switchMap(input, fn, true)
+	}
+}
+
+// ExhaustMap projects each source value to an Observable which is merged in the output Observable,
+// only if the previously projected Observable has completed, otherwise the source value is ignored.
+func ExhaustMap[I, O any](fn func(v I, index int) Observable[O]) OperatorFunc[I, O] {
+	return func(input Observable[I]) Observable[O] {
+		return switchMap(input, fn, false)
+	}
+}
 
-			var i int
+// switchMap subscribes to the projected Observables one at a time,
+// a source value emitted while the projected Observable is active either replaces it (switch) or is ignored.
+func switchMap[I, O any](input Observable[I], fn func(v I, index int) Observable[O], switching bool) Observable[O] {
+	return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
 
-			for {
-				v, err, ok := next()
-				if err != nil {
+		source := subscribeAsync(ctx, &wg, input)
+
+		var (
+			i           int
+			inner       <-chan state[O]
+			cancelInner context.CancelFunc = func() {}
+			completed   bool
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case o := <-source:
+				if o.err != nil {
 					var zero O
-					yield(zero, err)
+					yield(zero, o.err)
 					return
-				} else if !ok {
+				} else if !o.ok {
+					// The output completes once the source and the active projected Observable are both completed.
+					if inner == nil {
+						return
+					}
+					completed, source = true, nil
+				} else if inner == nil || switching {
+					cancelInner()
+					innerCtx, c := context.WithCancel(ctx)
+					cancelInner = c
+					inner = subscribeAsync(innerCtx, &wg, fn(o.v, i))
+					i++
+				}
+			case o := <-inner:
+				if o.err != nil {
+					var zero O
+					yield(zero, o.err)
 					return
+				} else if !o.ok {
+					cancelInner()
+					if completed {
+						return
+					}
+					inner = nil
 				} else {
-					next2, stop2 := iter.Pull2(fn(v, i).Subscribe())
+					if !yield(o.v, nil) {
+						return
+					}
+				}
+			}
+		}
+	})
+}
 
-				loop2:
-					for {
-						v2, err2, ok2 := next2()
-						if err2 != nil {
-							stop2()
-							var zero O
-							yield(zero, err2)
-							return
-						} else if !ok2 {
-							break loop2
-						} else {
-							if !yield(v2, nil) {
-								stop2()
-								return
+// MergeMap projects each source value to an Observable which is merged in the output Observable.
+// The projected Observables are subscribed concurrently, at most concurrent of them at a time (unlimited by default, or if lower or equal to zero),
+// the source is paused until one of them completes once the limit is reached.
+// The first error, from the source or a projected Observable, is propagated and every other subscription is cancelled.
+func MergeMap[I, O any](fn func(v I, index int) Observable[O], concurrent ...int) OperatorFunc[I, O] {
+	limit := -1
+	if len(concurrent) > 0 && concurrent[0] > 0 {
+		limit = concurrent[0]
+	}
+	return func(input Observable[I]) Observable[O] {
+		return (ObservableContextFunc[O])(func(ctx context.Context, yield func(O, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := make(chan state[O])
+			wg.Go(func() {
+				sourceCtx, cancelSource := context.WithCancel(ctx)
+				defer cancelSource()
+
+				g, innerCtx := errgroup.WithContext(sourceCtx)
+				g.SetLimit(limit)
+
+				var (
+					i         int
+					sourceErr error
+				)
+				for v, err := range input.SubscribeContext(innerCtx) {
+					if err != nil {
+						// The error of a cancelled source is the consequence of another error.
+						if innerCtx.Err() == nil {
+							sourceErr = err
+							cancelSource()
+						}
+						break
+					}
+					g.Go(func(v I, index int) func() error {
+						return func() error {
+							for v2, err2 := range fn(v, index).SubscribeContext(innerCtx) {
+								if err2 != nil {
+									if innerCtx.Err() != nil {
+										return nil
+									}
+									return err2
+								}
+								select {
+								case <-innerCtx.Done():
+									return nil
+								case ch <- state[O]{v2, nil, true}:
+								}
 							}
+							return nil
+						}
+					}(v, i))
+					i++
+				}
+
+				err := g.Wait()
+				if sourceErr != nil {
+					err = sourceErr
+				}
+				select {
+				case <-ctx.Done():
+				case ch <- state[O]{err: err}:
+				}
+			})
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-ch:
+					if o.err != nil {
+						var zero O
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					} else {
+						if !yield(o.v, nil) {
+							return
 						}
 					}
-					stop2()
 				}
-				i++
 			}
 		})
 	}
 }
 
-// MergeMap projects each source value to an Observable which is merged in the output Observable.
-func MergeMap[T any](fn func(v T, index int) Observable[T]) OperatorFunc[T, T] {
-	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
+// GroupedObservable is an Observable of the values of the source sharing the same key, see GroupBy.
+type GroupedObservable[K comparable, T any] interface {
+	Observable[T]
+	// Key returns the key shared by every value of the group.
+	Key() K
+}
 
-			var i int
+type groupedObservable[K comparable, T any] struct {
+	queueObservable[T]
+	key K
+}
 
-			for {
-				v, err, ok := next()
-				if err != nil {
-					var zero T
-					yield(zero, err)
-					return
-				} else if !ok {
-					return
-				} else {
-					next2, stop2 := iter.Pull2(fn(v, i).Subscribe())
+// Key returns the key shared by every value of the group.
+func (g *groupedObservable[K, T]) Key() K {
+	return g.key
+}
 
-				loop2:
-					for {
-						v2, err2, ok2 := next2()
-						if err2 != nil {
-							var zero T
-							yield(zero, err2)
+// GroupByOptions customises the groups created by GroupBy.
+type GroupByOptions[K comparable, T any] struct {
+	// Duration returns an Observable which completes the group as soon as it emits or completes,
+	// the next value with the same key creates a new group.
+	// The group it receives yields every value of the group, e.g. Pipe1(group, DebounceTime[T](time.Minute)) expires idle groups.
+	Duration func(group GroupedObservable[K, T]) Observable[T]
+	// BufferSize bounds the number of values each group buffers for its subscriber, it's unbounded if lower or equal to zero.
+	// The source is never paused by a full group, so a slow or unsubscribed group doesn't hold back the others.
+	// The values buffered for the group given to Duration are bounded the same way, with the same Overflow,
+	// so a Duration which doesn't read its group, such as a timer, should come with a drop policy.
+	BufferSize int
+	// Overflow decides what happens to a value of a group whose buffer is full.
+	// With OverflowError (the default), the group terminates with ErrBufferOverflow and the value creates a new group,
+	// unless the full buffer is the one of Duration: the value has been delivered to the group already.
+	// OverflowDropNewest and OverflowDropOldest drop a value of the buffer instead.
+	Overflow OverflowPolicy
+}
+
+// GroupBy groups the values emitted by the source Observable according to the key returned by keySelector,
+// and emits each group as a GroupedObservable the first time a value of its key is emitted.
+//
+// The values are buffered until the group is subscribed, a group delivers each of its values to a single subscriber.
+// The groups complete when the source completes, and receive the error of the source or of a Duration Observable.
+func GroupBy[T any, K comparable](keySelector func(v T) K, opts ...GroupByOptions[K, T]) OperatorFunc[T, GroupedObservable[K, T]] {
+	return GroupByElement(keySelector, func(v T) T { return v }, opts...)
+}
+
+// GroupByElement is similar to GroupBy but the groups emit the value returned by elementSelector for each value of the source.
+func GroupByElement[T any, K comparable, E any](keySelector func(v T) K, elementSelector func(v T) E, opts ...GroupByOptions[K, E]) OperatorFunc[T, GroupedObservable[K, E]] {
+	var opt GroupByOptions[K, E]
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return func(input Observable[T]) Observable[GroupedObservable[K, E]] {
+		return (ObservableContextFunc[GroupedObservable[K, E]])(func(ctx context.Context, yield func(GroupedObservable[K, E], error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			var (
+				mu     sync.Mutex
+				groups = make(map[K]*groupedObservable[K, E])
+				// The durations of the groups are fed by another queue, so they don't compete with the subscriber of the group.
+				durations = make(map[*groupedObservable[K, E]]*queue[E])
+				output    = newQueue[GroupedObservable[K, E]](0)
+			)
+			terminate := func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, g := range groups {
+					g.values.close(err)
+					if d, ok := durations[g]; ok {
+						d.close(err)
+					}
+				}
+				clear(groups)
+				clear(durations)
+				output.close(err)
+			}
+			// expire terminates the group with err, the next value with the same key creates a new group.
+			expire := func(g *groupedObservable[K, E], err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if groups[g.key] == g {
+					delete(groups, g.key)
+				}
+				if d, ok := durations[g]; ok {
+					d.close(nil)
+					delete(durations, g)
+				}
+				g.values.close(err)
+			}
+			group := func(key K) (*groupedObservable[K, E], *queue[E]) {
+				mu.Lock()
+				defer mu.Unlock()
+				if g, ok := groups[key]; ok {
+					return g, durations[g]
+				}
+
+				g := &groupedObservable[K, E]{queueObservable[E]{newQueue[E](opt.BufferSize)}, key}
+				groups[key] = g
+				output.push(ctx, g)
+				if opt.Duration == nil {
+					return g, nil
+				}
+
+				d := newQueue[E](opt.BufferSize)
+				durations[g] = d
+				duration := opt.Duration(&groupedObservable[K, E]{queueObservable[E]{d}, key})
+				wg.Go(func() {
+					for _, err := range duration.SubscribeContext(ctx) {
+						if err != nil {
+							if ctx.Err() == nil {
+								terminate(err)
+							}
 							return
-						} else if !ok2 {
-							break loop2
-						} else {
-							if !yield(v2, nil) {
-								return
+						}
+						break
+					}
+					if ctx.Err() == nil {
+						expire(g, nil)
+					}
+				})
+				return g, d
+			}
+
+			wg.Go(func() {
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						terminate(err)
+						return
+					}
+					key := keySelector(v)
+					e := elementSelector(v)
+					for {
+						g, d := group(key)
+						// The group may have expired in the meantime, the value then goes to a new group.
+						ok, err := g.values.offer(e, opt.Overflow)
+						if err != nil {
+							expire(g, err)
+							continue
+						}
+						if ok && d != nil {
+							if _, err := d.offer(e, opt.Overflow); err != nil {
+								expire(g, err)
 							}
 						}
+						if ok || ctx.Err() != nil {
+							break
+						}
 					}
-					stop2()
 				}
-				i++
-			}
+				terminate(nil)
+			})
+
+			output.drain(ctx, yield)
 		})
 	}
 }
@@ -288,9 +726,9 @@ func MergeMap[T any](fn func(v T, index int) Observable[T]) OperatorFunc[T, T] {
 // Pairwise groups pairs of consecutive emissions together and emits them as an array of two values.
 func Pairwise[T any]() OperatorFunc[T, [2]T] {
 	return func(input Observable[T]) Observable[[2]T] {
-		return (ObservableFunc[[2]T])(func(yield func([2]T, error) bool) {
+		return (ObservableContextFunc[[2]T])(func(ctx context.Context, yield func([2]T, error) bool) {
 			pair := make([]T, 0, 2)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero [2]T
 					yield(zero, err)
@@ -312,12 +750,12 @@ func Pairwise[T any]() OperatorFunc[T, [2]T] {
 // Scan applies an accumulator function over the source Observable, and returns each intermediate result, with the specified seed as the initial accumulator value.
 func Scan[V, A any](accumulator func(acc A, value V, index int) A, seed A) OperatorFunc[V, A] {
 	return func(input Observable[V]) Observable[A] {
-		return (ObservableFunc[A])(func(yield func(A, error) bool) {
+		return (ObservableContextFunc[A])(func(ctx context.Context, yield func(A, error) bool) {
 			var (
 				acc = seed
 				i   int
 			)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					var zero A
 					yield(zero, err)
@@ -1,48 +1,168 @@
 package rx
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Either represents a value of one of two possible types (a disjoint union).
+// It records which side it holds, so it works even when A and B are the same type.
+// The zero value holds the zero value of A.
 type Either[A, B any] struct {
-	v any
+	a     A
+	b     B
+	right bool
 }
 
 // NewA creates an Either with the left value.
 func NewA[B, A any](v A) Either[A, B] {
-	return Either[A, B]{v: v}
+	return Either[A, B]{a: v}
 }
 
 // NewB creates an Either with the right value.
 func NewB[A, B any](v B) Either[A, B] {
-	return Either[A, B]{v: v}
+	return Either[A, B]{b: v, right: true}
+}
+
+// IsA reports whether the Either holds the left value.
+func (a Either[A, B]) IsA() bool {
+	return !a.right
+}
+
+// IsB reports whether the Either holds the right value.
+func (a Either[A, B]) IsB() bool {
+	return a.right
 }
 
 // A returns the left value and a boolean indicating if it exists.
 func (a Either[A, B]) A() (A, bool) {
-	if v, ok := a.v.(A); ok {
-		return v, true
-	}
-	var zero A
-	return zero, false
+	return a.a, !a.right
 }
 
 // B returns the right value and a boolean indicating if it exists.
 func (a Either[A, B]) B() (B, bool) {
-	if v, ok := a.v.(B); ok {
-		return v, true
-	}
-	var zero B
-	return zero, false
+	return a.b, a.right
 }
 
-// MustA returns the left value. It panics if the value is not of type A.
+// MustA returns the left value. It panics if the Either holds the right value.
 func (a Either[A, B]) MustA() A {
-	return a.v.(A)
+	if a.right {
+		panic(`rxgo: MustA called on the right value of an Either`)
+	}
+	return a.a
 }
 
-// MustB returns the right value. It panics if the value is not of type B.
+// MustB returns the right value. It panics if the Either holds the left value.
 func (a Either[A, B]) MustB() B {
-	return a.v.(B)
+	if !a.right {
+		panic(`rxgo: MustB called on the left value of an Either`)
+	}
+	return a.b
+}
+
+// Match calls onA or onB with the value held by the Either.
+func (a Either[A, B]) Match(onA func(A), onB func(B)) {
+	if a.right {
+		onB(a.b)
+	} else {
+		onA(a.a)
+	}
+}
+
+func (a Either[A, B]) String() string {
+	if a.right {
+		return fmt.Sprintf("B(%v)", a.b)
+	}
+	return fmt.Sprintf("A(%v)", a.a)
+}
+
+// Fold reduces the Either to a single value, with onA or onB depending on the value it holds.
+func Fold[A, B, R any](e Either[A, B], onA func(A) R, onB func(B) R) R {
+	if e.right {
+		return onB(e.b)
+	}
+	return onA(e.a)
+}
+
+// OneOf3 represents a value of one of three possible types, it's the three-way counterpart of Either.
+// The zero value holds the zero value of A.
+type OneOf3[A, B, C any] struct {
+	a     A
+	b     B
+	c     C
+	index int
+}
+
+// NewOneOf3A creates a OneOf3 with the first value.
+func NewOneOf3A[B, C, A any](v A) OneOf3[A, B, C] {
+	return OneOf3[A, B, C]{a: v}
+}
+
+// NewOneOf3B creates a OneOf3 with the second value.
+func NewOneOf3B[A, C, B any](v B) OneOf3[A, B, C] {
+	return OneOf3[A, B, C]{b: v, index: 1}
+}
+
+// NewOneOf3C creates a OneOf3 with the third value.
+func NewOneOf3C[A, B, C any](v C) OneOf3[A, B, C] {
+	return OneOf3[A, B, C]{c: v, index: 2}
+}
+
+// Index returns the position of the value held by the OneOf3: 0 for A, 1 for B and 2 for C.
+func (o OneOf3[A, B, C]) Index() int {
+	return o.index
+}
+
+// A returns the first value and a boolean indicating if it exists.
+func (o OneOf3[A, B, C]) A() (A, bool) {
+	return o.a, o.index == 0
+}
+
+// B returns the second value and a boolean indicating if it exists.
+func (o OneOf3[A, B, C]) B() (B, bool) {
+	return o.b, o.index == 1
+}
+
+// C returns the third value and a boolean indicating if it exists.
+func (o OneOf3[A, B, C]) C() (C, bool) {
+	return o.c, o.index == 2
+}
+
+// Match calls onA, onB or onC with the value held by the OneOf3.
+func (o OneOf3[A, B, C]) Match(onA func(A), onB func(B), onC func(C)) {
+	switch o.index {
+	case 1:
+		onB(o.b)
+	case 2:
+		onC(o.c)
+	default:
+		onA(o.a)
+	}
+}
+
+func (o OneOf3[A, B, C]) String() string {
+	switch o.index {
+	case 1:
+		return fmt.Sprintf("B(%v)", o.b)
+	case 2:
+		return fmt.Sprintf("C(%v)", o.c)
+	default:
+		return fmt.Sprintf("A(%v)", o.a)
+	}
+}
+
+// FoldOneOf3 reduces the OneOf3 to a single value, with onA, onB or onC depending on the value it holds.
+func FoldOneOf3[A, B, C, R any](o OneOf3[A, B, C], onA func(A) R, onB func(B) R, onC func(C) R) R {
+	switch o.index {
+	case 1:
+		return onB(o.b)
+	case 2:
+		return onC(o.c)
+	default:
+		return onA(o.a)
+	}
 }
 
 // Tuple represents a pair of values.
@@ -56,6 +176,31 @@ func NewTuple[L, R any](left L, right R) Tuple[L, R] {
 	return Tuple[L, R]{left, right}
 }
 
+// Tuple3 represents a triple of values.
+type Tuple3[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// NewTuple3 creates a new Tuple3.
+func NewTuple3[A, B, C any](first A, second B, third C) Tuple3[A, B, C] {
+	return Tuple3[A, B, C]{first, second, third}
+}
+
+// Tuple4 represents a quadruple of values.
+type Tuple4[A, B, C, D any] struct {
+	First  A
+	Second B
+	Third  C
+	Fourth D
+}
+
+// NewTuple4 creates a new Tuple4.
+func NewTuple4[A, B, C, D any](first A, second B, third C, fourth D) Tuple4[A, B, C, D] {
+	return Tuple4[A, B, C, D]{first, second, third, fourth}
+}
+
 // TimeInterval represents a value emitted by an Observable with the time interval since the last emission.
 type TimeInterval[T any] struct {
 	Interval time.Duration
@@ -67,3 +212,129 @@ type Timestamp[T any] struct {
 	Time  time.Time
 	Value T
 }
+
+// NotificationKind is the kind of a Notification.
+type NotificationKind int
+
+const (
+	// KindNext is a value notification.
+	KindNext NotificationKind = iota
+	// KindError is an error notification.
+	KindError
+	// KindComplete is a completion notification.
+	KindComplete
+)
+
+func (k NotificationKind) String() string {
+	switch k {
+	case KindNext:
+		return "next"
+	case KindError:
+		return "error"
+	case KindComplete:
+		return "complete"
+	default:
+		return fmt.Sprintf("NotificationKind(%d)", int(k))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k NotificationKind) MarshalText() ([]byte, error) {
+	switch k {
+	case KindNext, KindError, KindComplete:
+		return []byte(k.String()), nil
+	default:
+		return nil, fmt.Errorf("rxgo: invalid notification kind %d", int(k))
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *NotificationKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "next":
+		*k = KindNext
+	case "error":
+		*k = KindError
+	case "complete":
+		*k = KindComplete
+	default:
+		return fmt.Errorf("rxgo: invalid notification kind %q", text)
+	}
+	return nil
+}
+
+// Notification represents an event of an Observable as a value: the emission of Value, the error Err or the completion.
+type Notification[T any] struct {
+	Kind  NotificationKind
+	Value T
+	Err   error
+}
+
+// NextNotification creates a Notification of the value v.
+func NextNotification[T any](v T) Notification[T] {
+	return Notification[T]{Kind: KindNext, Value: v}
+}
+
+// ErrorNotification creates a Notification of the error err.
+func ErrorNotification[T any](err error) Notification[T] {
+	return Notification[T]{Kind: KindError, Err: err}
+}
+
+// CompleteNotification creates a completion Notification.
+func CompleteNotification[T any]() Notification[T] {
+	return Notification[T]{Kind: KindComplete}
+}
+
+func (n Notification[T]) String() string {
+	switch n.Kind {
+	case KindNext:
+		return fmt.Sprintf("next(%v)", n.Value)
+	case KindError:
+		return fmt.Sprintf("error(%v)", n.Err)
+	default:
+		return n.Kind.String()
+	}
+}
+
+type notificationJSON[T any] struct {
+	Kind  NotificationKind `json:"kind"`
+	Value *T               `json:"value,omitempty"`
+	Err   *string          `json:"error,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, the error is serialized as its message.
+func (n Notification[T]) MarshalJSON() ([]byte, error) {
+	v := notificationJSON[T]{Kind: n.Kind}
+	switch n.Kind {
+	case KindNext:
+		v.Value = &n.Value
+	case KindError:
+		msg := "<nil>"
+		if n.Err != nil {
+			msg = n.Err.Error()
+		}
+		v.Err = &msg
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the error is restored with errors.New, so it only keeps its message.
+func (n *Notification[T]) UnmarshalJSON(b []byte) error {
+	var v notificationJSON[T]
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*n = Notification[T]{Kind: v.Kind}
+	switch v.Kind {
+	case KindNext:
+		if v.Value != nil {
+			n.Value = *v.Value
+		}
+	case KindError:
+		if v.Err == nil {
+			return errors.New(`rxgo: missing error of the error notification`)
+		}
+		n.Err = errors.New(*v.Err)
+	}
+	return nil
+}
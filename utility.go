@@ -2,15 +2,17 @@ package rx
 
 import (
 	"context"
+	"fmt"
 	"iter"
+	"sync"
 	"time"
 )
 
 // Tap performs a side effect for every emission on the source Observable, but returns an Observable that is identical to the source.
 func Tap[T any](fn func(T)) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -25,13 +27,93 @@ func Tap[T any](fn func(T)) OperatorFunc[T, T] {
 	}
 }
 
+// TapObserver is the set of callbacks of TapWith, a nil callback is ignored.
+type TapObserver[T any] struct {
+	// Next is called for each value emitted by the source.
+	Next func(v T)
+	// Error is called when the source errors.
+	Error func(err error)
+	// Complete is called when the source completes.
+	Complete func()
+	// Subscribe is called before subscribing to the source.
+	Subscribe func()
+	// Unsubscribe is called when the consumer stops, or the context is done, before the source terminates.
+	Unsubscribe func()
+}
+
+// TapWith is similar to Tap but also observes the error, the completion and the lifecycle of the subscription.
+func TapWith[T any](observer TapObserver[T]) OperatorFunc[T, T] {
+	call := func(fn func()) {
+		if fn != nil {
+			fn()
+		}
+	}
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			call(observer.Subscribe)
+			for v, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					if ctx.Err() != nil {
+						call(observer.Unsubscribe)
+					} else if observer.Error != nil {
+						observer.Error(err)
+					}
+					yield(v, err)
+					return
+				} else {
+					if observer.Next != nil {
+						observer.Next(v)
+					}
+					if !yield(v, nil) {
+						call(observer.Unsubscribe)
+						return
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				call(observer.Unsubscribe)
+			} else {
+				call(observer.Complete)
+			}
+		})
+	}
+}
+
+// Finalize calls fn once the subscription to the source Observable ends, whether it completes, errors, or the consumer stops early.
+// fn is called exactly once per subscription, even if the source panics.
+func Finalize[T any](fn func()) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			defer fn()
+			for v, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					yield(v, err)
+					return
+				} else {
+					if !yield(v, nil) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
 // Delay delays the emissions of items from the source Observable by a given timeout or until a given Date.
-func Delay[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func Delay[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			<-time.After(duration)
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			timer := clockOf(ctx, clock).NewTimer(duration)
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C():
+			}
 
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(v, err)
 					return
@@ -48,8 +130,8 @@ func Delay[T any](duration time.Duration) OperatorFunc[T, T] {
 // DelayWhen delays the emission of items from the source Observable by a given time span determined by the emissions of another Observable.
 func DelayWhen[T, R any](delayDurationSelector func(value T, index int) Observable[R]) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			next, stop := iter.Pull2(input.Subscribe())
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			next, stop := iter.Pull2(input.SubscribeContext(ctx))
 			defer stop()
 
 			var i int
@@ -61,7 +143,15 @@ func DelayWhen[T, R any](delayDurationSelector func(value T, index int) Observab
 				} else if !ok {
 					return
 				} else {
-					delayDurationSelector(v, i).Subscribe()
+					// The value is emitted once the duration Observable emits its first value or completes.
+					for _, err := range delayDurationSelector(v, i).SubscribeContext(ctx) {
+						if err != nil {
+							var zero T
+							yield(zero, err)
+							return
+						}
+						break
+					}
 					if !yield(v, nil) {
 						return
 					}
@@ -72,17 +162,104 @@ func DelayWhen[T, R any](delayDurationSelector func(value T, index int) Observab
 	}
 }
 
+// Repeat resubscribes to the source Observable once it completes, so its values are emitted count times.
+// The source is repeated forever if count is negative.
+func Repeat[T any](count int) OperatorFunc[T, T] {
+	return RepeatWithDelay[T](count, 0)
+}
+
+// RepeatWithDelay is similar to Repeat but waits for delay between the completion of the source and the next subscription,
+// which makes a polling Observable out of a Defer.
+// The optional clock overrides the Clock of the pipeline.
+func RepeatWithDelay[T any](count int, delay time.Duration, clock ...Clock) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			for i := 0; count < 0 || i < count; i++ {
+				if i > 0 && delay > 0 {
+					timer := clockOf(ctx, clock).NewTimer(delay)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return
+					case <-timer.C():
+					}
+				}
+
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						yield(v, err)
+						return
+					} else {
+						if !yield(v, nil) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// RepeatWhen resubscribes to the source Observable each time the Observable returned by notifier emits.
+// The notifier is called once with the Observable of the completions of the source, so it decides when and whether to repeat:
+// the output Observable completes when it completes, and errors when it errors.
+func RepeatWhen[T, N any](notifier func(completions Observable[struct{}]) Observable[N]) OperatorFunc[T, T] {
+	return func(input Observable[T]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			completions := newQueue[struct{}](0)
+			defer completions.close(nil)
+			notifications := subscribeAsync(ctx, &wg, notifier(queueObservable[struct{}]{completions}))
+
+			for {
+				for v, err := range input.SubscribeContext(ctx) {
+					if err != nil {
+						yield(v, err)
+						return
+					} else {
+						if !yield(v, nil) {
+							return
+						}
+					}
+				}
+				if !completions.push(ctx, struct{}{}) {
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-notifications:
+					if o.err != nil {
+						var zero T
+						yield(zero, o.err)
+						return
+					} else if !o.ok {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
 // WithTimeInterval adds the time interval since the last emission to the emitted value.
-func WithTimeInterval[T any]() OperatorFunc[T, TimeInterval[T]] {
+// The optional clock overrides the Clock of the pipeline.
+func WithTimeInterval[T any](clock ...Clock) OperatorFunc[T, TimeInterval[T]] {
 	return func(input Observable[T]) Observable[TimeInterval[T]] {
-		return (ObservableFunc[TimeInterval[T]])(func(yield func(TimeInterval[T], error) bool) {
-			startFrom := time.Now().UTC()
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[TimeInterval[T]])(func(ctx context.Context, yield func(TimeInterval[T], error) bool) {
+			c := clockOf(ctx, clock)
+			startFrom := c.Now()
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(TimeInterval[T]{}, err)
 					return
 				} else {
-					if !yield(TimeInterval[T]{Interval: time.Since(startFrom), Value: v}, nil) {
+					if !yield(TimeInterval[T]{Interval: c.Now().Sub(startFrom), Value: v}, nil) {
 						return
 					}
 				}
@@ -92,15 +269,17 @@ func WithTimeInterval[T any]() OperatorFunc[T, TimeInterval[T]] {
 }
 
 // WithTimestamp attaches a timestamp to each item emitted by an observable indicating when it was emitted.
-func WithTimestamp[T any]() OperatorFunc[T, Timestamp[T]] {
+// The optional clock overrides the Clock of the pipeline.
+func WithTimestamp[T any](clock ...Clock) OperatorFunc[T, Timestamp[T]] {
 	return func(input Observable[T]) Observable[Timestamp[T]] {
-		return (ObservableFunc[Timestamp[T]])(func(yield func(Timestamp[T], error) bool) {
-			for v, err := range input.Subscribe() {
+		return (ObservableContextFunc[Timestamp[T]])(func(ctx context.Context, yield func(Timestamp[T], error) bool) {
+			c := clockOf(ctx, clock)
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(Timestamp[T]{}, err)
 					return
 				} else {
-					if !yield(Timestamp[T]{Time: time.Now().UTC(), Value: v}, nil) {
+					if !yield(Timestamp[T]{Time: c.Now().UTC(), Value: v}, nil) {
 						return
 					}
 				}
@@ -110,60 +289,55 @@ func WithTimestamp[T any]() OperatorFunc[T, Timestamp[T]] {
 }
 
 // Timeout errors if the Observable does not emit a value within a specified time.
-func Timeout[T any](duration time.Duration) OperatorFunc[T, T] {
+// The optional clock overrides the Clock of the pipeline.
+func Timeout[T any](duration time.Duration, clock ...Clock) OperatorFunc[T, T] {
 	return func(input Observable[T]) Observable[T] {
-		return (ObservableFunc[T])(func(yield func(T, error) bool) {
-			ctx, cancel := context.WithTimeout(context.Background(), duration)
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
 			defer cancel()
 
-			next, stop := iter.Pull2(input.Subscribe())
-			defer stop()
-
-			ch := make(chan state[T], 1)
-			defer close(ch)
+			ch := subscribeAsync(ctx, &wg, input)
 
-			go func() {
-				v, err, ok := next()
-				select {
-				case <-ctx.Done():
-					return
-				case ch <- state[T]{v, err, ok}:
-					cancel()
-					if err != nil || !ok {
-						return
-					}
-				}
-			}()
+			timer := clockOf(ctx, clock).NewTimer(duration)
+			defer timer.Stop()
 
 			select {
 			case <-ctx.Done():
+				return
+			case <-timer.C():
 				var zero T
 				yield(zero, ErrTimeout)
 				return
-
-			case r := <-ch:
-				if r.err != nil {
+			case o := <-ch:
+				timer.Stop()
+				if o.err != nil {
 					var zero T
-					yield(zero, r.err)
+					yield(zero, o.err)
 					return
-				} else if !r.ok {
+				} else if !o.ok {
 					return
 				} else {
-					if !yield(r.v, nil) {
+					if !yield(o.v, nil) {
 						return
 					}
 				}
+			}
 
-				for {
-					v, err, ok := next()
-					if err != nil {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case o := <-ch:
+					if o.err != nil {
 						var zero T
-						yield(zero, err)
+						yield(zero, o.err)
 						return
-					} else if !ok {
+					} else if !o.ok {
 						return
 					} else {
-						if !yield(v, nil) {
+						if !yield(o.v, nil) {
 							return
 						}
 					}
@@ -173,12 +347,66 @@ func Timeout[T any](duration time.Duration) OperatorFunc[T, T] {
 	}
 }
 
+// Materialize represents all of the notifications from the source Observable as Notification values,
+// so the error and the completion can be handled as regular values.
+// The output Observable emits the terminal Notification of the source, then completes, it never errors.
+func Materialize[T any]() OperatorFunc[T, Notification[T]] {
+	return func(input Observable[T]) Observable[Notification[T]] {
+		return (ObservableContextFunc[Notification[T]])(func(ctx context.Context, yield func(Notification[T], error) bool) {
+			for v, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					yield(ErrorNotification[T](err), nil)
+					return
+				} else {
+					if !yield(NextNotification(v), nil) {
+						return
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			yield(CompleteNotification[T](), nil)
+		})
+	}
+}
+
+// Dematerialize converts an Observable of Notification values into the emissions that they represent.
+// It completes at the first completion Notification, and errors at the first error Notification.
+func Dematerialize[T any]() OperatorFunc[Notification[T], T] {
+	return func(input Observable[Notification[T]]) Observable[T] {
+		return (ObservableContextFunc[T])(func(ctx context.Context, yield func(T, error) bool) {
+			var zero T
+			for n, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					yield(zero, err)
+					return
+				}
+				switch n.Kind {
+				case KindNext:
+					if !yield(n.Value, nil) {
+						return
+					}
+				case KindError:
+					yield(zero, n.Err)
+					return
+				case KindComplete:
+					return
+				default:
+					yield(zero, fmt.Errorf("rxgo: invalid notification kind %d", int(n.Kind)))
+					return
+				}
+			}
+		})
+	}
+}
+
 // ToSlice collects all values from the source Observable into a slice.
 func ToSlice[T any]() OperatorFunc[T, []T] {
 	return func(input Observable[T]) Observable[[]T] {
-		return (ObservableFunc[[]T])(func(yield func([]T, error) bool) {
+		return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
 			result := make([]T, 0)
-			for v, err := range input.Subscribe() {
+			for v, err := range input.SubscribeContext(ctx) {
 				if err != nil {
 					yield(nil, err)
 					return
@@ -190,3 +418,46 @@ func ToSlice[T any]() OperatorFunc[T, []T] {
 		})
 	}
 }
+
+// ToMap collects all the values of the source Observable into a map keyed by keySelector, and emits it once the source completes.
+// The last value wins when several values have the same key.
+func ToMap[T any, K comparable](keySelector func(v T) K) OperatorFunc[T, map[K]T] {
+	return ToMapOf(keySelector, func(v T) T { return v })
+}
+
+// ToMapOf is similar to ToMap but stores the result of valueSelector instead of the values themselves.
+func ToMapOf[T any, K comparable, V any](keySelector func(v T) K, valueSelector func(v T) V) OperatorFunc[T, map[K]V] {
+	return func(input Observable[T]) Observable[map[K]V] {
+		return (ObservableContextFunc[map[K]V])(func(ctx context.Context, yield func(map[K]V, error) bool) {
+			result := make(map[K]V)
+			for v, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					yield(nil, err)
+					return
+				} else {
+					result[keySelector(v)] = valueSelector(v)
+				}
+			}
+			yield(result, nil)
+		})
+	}
+}
+
+// ToMultiMap is similar to ToMap but keeps every value of a key, in the order of the source.
+func ToMultiMap[T any, K comparable](keySelector func(v T) K) OperatorFunc[T, map[K][]T] {
+	return func(input Observable[T]) Observable[map[K][]T] {
+		return (ObservableContextFunc[map[K][]T])(func(ctx context.Context, yield func(map[K][]T, error) bool) {
+			result := make(map[K][]T)
+			for v, err := range input.SubscribeContext(ctx) {
+				if err != nil {
+					yield(nil, err)
+					return
+				} else {
+					k := keySelector(v)
+					result[k] = append(result[k], v)
+				}
+			}
+			yield(result, nil)
+		})
+	}
+}
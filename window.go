@@ -0,0 +1,433 @@
+package rx
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// window is an open window, its values are either streamed as a nested Observable or collected into a buffer.
+type window[T any] struct {
+	values *queue[T]
+	buffer []T
+}
+
+// windowing routes the values of the source to the windows which are open.
+// With windows, every window is emitted to output as soon as it opens, and is buffered until it's subscribed,
+// so the windows can be consumed one after the other (e.g. with ConcatMap).
+// With buffers, the values of every window are collected and emitted to buffers once the window closes.
+type windowing[T any] struct {
+	ctx     context.Context
+	output  *queue[Observable[T]]
+	buffers *queue[[]T]
+	opened  []*window[T]
+	// skipEmpty drops the empty buffers which are still open when the source completes.
+	skipEmpty bool
+}
+
+// open opens a new window.
+func (w *windowing[T]) open() *window[T] {
+	win := &window[T]{}
+	if w.buffers != nil {
+		win.buffer = make([]T, 0)
+	} else {
+		win.values = newQueue[T](0)
+		w.output.push(w.ctx, queueObservable[T]{win.values})
+	}
+	w.opened = append(w.opened, win)
+	return win
+}
+
+// next emits v to every open window.
+func (w *windowing[T]) next(v T) {
+	for _, win := range w.opened {
+		if win.values != nil {
+			win.values.push(w.ctx, v)
+		} else {
+			win.buffer = append(win.buffer, v)
+		}
+	}
+}
+
+// close completes the window win.
+func (w *windowing[T]) close(win *window[T]) {
+	w.opened = slices.DeleteFunc(w.opened, func(v *window[T]) bool {
+		return v == win
+	})
+	if win.values != nil {
+		win.values.close(nil)
+	} else {
+		w.buffers.push(w.ctx, win.buffer)
+	}
+}
+
+// terminate terminates every open window and the output with err.
+// Once the source completes, the buffers which are still open are emitted.
+func (w *windowing[T]) terminate(err error) {
+	for _, win := range w.opened {
+		if win.values != nil {
+			win.values.close(err)
+		} else if err == nil && (len(win.buffer) > 0 || !w.skipEmpty) {
+			w.buffers.push(w.ctx, win.buffer)
+		}
+	}
+	w.opened = nil
+	if w.buffers != nil {
+		w.buffers.close(err)
+	} else {
+		w.output.close(err)
+	}
+}
+
+// windows runs route on its own goroutine and emits each window as a nested Observable.
+// route returns the error terminating the windows, or nil once the source completes.
+func windows[T any](route func(w *windowing[T]) error) Observable[Observable[T]] {
+	return (ObservableContextFunc[Observable[T]])(func(ctx context.Context, yield func(Observable[T], error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		w := &windowing[T]{ctx: ctx, output: newQueue[Observable[T]](0)}
+		wg.Go(func() {
+			w.terminate(route(w))
+		})
+		w.output.drain(ctx, yield)
+	})
+}
+
+// buffers is similar to windows but emits each window as a slice once it closes.
+func buffers[T any](skipEmpty bool, route func(w *windowing[T]) error) Observable[[]T] {
+	return (ObservableContextFunc[[]T])(func(ctx context.Context, yield func([]T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		w := &windowing[T]{ctx: ctx, buffers: newQueue[[]T](0), skipEmpty: skipEmpty}
+		wg.Go(func() {
+			w.terminate(route(w))
+		})
+		w.buffers.drain(ctx, yield)
+	})
+}
+
+// Window branches out the source Observable values as a nested Observable whenever windowBoundaries emits.
+// The first window is opened immediately, the last window completes with the source.
+func Window[T, I any](windowBoundaries Observable[I]) OperatorFunc[T, Observable[T]] {
+	return func(input Observable[T]) Observable[Observable[T]] {
+		return windows(func(w *windowing[T]) error {
+			ctx, cancel := context.WithCancel(w.ctx)
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer cancel()
+
+			ch := subscribeAsync(ctx, &wg, input)
+			boundaries := subscribeAsync(ctx, &wg, windowBoundaries)
+
+			current := w.open()
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case o := <-ch:
+					if o.err != nil {
+						return o.err
+					} else if !o.ok {
+						return nil
+					} else {
+						w.next(o.v)
+					}
+				case o := <-boundaries:
+					if o.err != nil {
+						return o.err
+					} else if !o.ok {
+						// The current window stays open until the source completes.
+						boundaries = nil
+					} else {
+						w.close(current)
+						current = w.open()
+					}
+				}
+			}
+		})
+	}
+}
+
+// WindowCount branches out the source Observable values as a nested Observable with each of them containing at most windowSize values.
+// A new window is opened every startWindowEvery values (windowSize by default), so the windows overlap if it's lower than windowSize.
+// It panics if windowSize is zero.
+func WindowCount[T any](windowSize uint, startWindowEvery ...uint) OperatorFunc[T, Observable[T]] {
+	if windowSize == 0 {
+		panic(`WindowCount required a positive window size`)
+	}
+	every := windowSize
+	if len(startWindowEvery) > 0 && startWindowEvery[0] > 0 {
+		every = startWindowEvery[0]
+	}
+	return func(input Observable[T]) Observable[Observable[T]] {
+		return windows(func(w *windowing[T]) error {
+			w.open()
+			var count uint
+			for v, err := range input.SubscribeContext(w.ctx) {
+				if err != nil {
+					return err
+				}
+				w.next(v)
+				// The oldest window is full once it has received windowSize values.
+				if count+1 >= windowSize && (count+1-windowSize)%every == 0 && len(w.opened) > 0 {
+					w.close(w.opened[0])
+				}
+				count++
+				if count%every == 0 {
+					w.open()
+				}
+			}
+			return nil
+		})
+	}
+}
+
+type timedWindow[T any] struct {
+	window   *window[T]
+	deadline time.Time
+	size     int
+}
+
+// WindowTimeOptions are the optional settings of WindowTime and BufferTime.
+type WindowTimeOptions struct {
+	// CreationInterval opens a new window periodically, so the windows overlap if it's lower than the time span.
+	// If it's lower or equal to zero, a new window is opened as soon as the previous one closes.
+	CreationInterval time.Duration
+	// MaxSize closes a window once it contains MaxSize values, if it's greater than zero.
+	MaxSize int
+	// Clock overrides the Clock of the pipeline.
+	Clock Clock
+}
+
+// WindowTime branches out the source Observable values as a nested Observable periodically in time.
+// Each window stays open for windowTimeSpan, a new one is opened as soon as the previous one closes unless WindowTimeOptions says otherwise.
+func WindowTime[T any](windowTimeSpan time.Duration, opts ...WindowTimeOptions) OperatorFunc[T, Observable[T]] {
+	return func(input Observable[T]) Observable[Observable[T]] {
+		return windows(routeTime(input, windowTimeSpan, opts))
+	}
+}
+
+// routeTime opens and closes the windows periodically, see WindowTime.
+func routeTime[T any](input Observable[T], timeSpan time.Duration, opts []WindowTimeOptions) func(w *windowing[T]) error {
+	var opt WindowTimeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return func(w *windowing[T]) error {
+		ctx, cancel := context.WithCancel(w.ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		c := clockOf(ctx, []Clock{opt.Clock})
+		ch := subscribeAsync(ctx, &wg, input)
+
+		var opened []*timedWindow[T]
+		timer := c.NewTimer(timeSpan)
+		defer timer.Stop()
+		start := func() {
+			opened = append(opened, &timedWindow[T]{window: w.open(), deadline: c.Now().Add(timeSpan)})
+		}
+		closeWindow := func(tw *timedWindow[T]) {
+			w.close(tw.window)
+			opened = slices.DeleteFunc(opened, func(v *timedWindow[T]) bool {
+				return v == tw
+			})
+			if opt.CreationInterval <= 0 {
+				start()
+			}
+		}
+		// The timer always fires at the deadline of the oldest window.
+		schedule := func() {
+			if len(opened) > 0 {
+				timer.Reset(opened[0].deadline.Sub(c.Now()))
+			} else {
+				timer.Stop()
+			}
+		}
+
+		var tick <-chan time.Time
+		if opt.CreationInterval > 0 {
+			ticker := c.NewTicker(opt.CreationInterval)
+			defer ticker.Stop()
+			tick = ticker.C()
+		}
+
+		start()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case o := <-ch:
+				if o.err != nil {
+					return o.err
+				} else if !o.ok {
+					return nil
+				}
+				w.next(o.v)
+				if opt.MaxSize > 0 {
+					for _, tw := range slices.Clone(opened) {
+						tw.size++
+						if tw.size >= opt.MaxSize {
+							closeWindow(tw)
+						}
+					}
+					schedule()
+				}
+			case <-timer.C():
+				now := c.Now()
+				for len(opened) > 0 && !opened[0].deadline.After(now) {
+					closeWindow(opened[0])
+				}
+				schedule()
+			case <-tick:
+				start()
+				if len(opened) == 1 {
+					schedule()
+				}
+			}
+		}
+	}
+}
+
+// WindowToggle branches out the source Observable values as a nested Observable starting each time openings emits,
+// and ending when the Observable returned by closingSelector emits.
+func WindowToggle[T, O, C any](openings Observable[O], closingSelector func(v O) Observable[C]) OperatorFunc[T, Observable[T]] {
+	return func(input Observable[T]) Observable[Observable[T]] {
+		return windows(routeToggle(input, openings, closingSelector))
+	}
+}
+
+// routeToggle opens a window each time openings emits, see WindowToggle.
+func routeToggle[T, O, C any](input Observable[T], openings Observable[O], closingSelector func(v O) Observable[C]) func(w *windowing[T]) error {
+	return func(w *windowing[T]) error {
+		ctx, cancel := context.WithCancel(w.ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		ch := subscribeAsync(ctx, &wg, input)
+		openCh := subscribeAsync(ctx, &wg, openings)
+
+		type closing struct {
+			window *window[T]
+			err    error
+		}
+		closings := make(chan closing)
+		cancels := make(map[*window[T]]context.CancelFunc)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case o := <-ch:
+				if o.err != nil {
+					return o.err
+				} else if !o.ok {
+					return nil
+				} else {
+					w.next(o.v)
+				}
+			case o := <-openCh:
+				if o.err != nil {
+					return o.err
+				} else if !o.ok {
+					openCh = nil
+					continue
+				}
+
+				win := w.open()
+				closingCtx, cancelClosing := context.WithCancel(ctx)
+				cancels[win] = cancelClosing
+				wg.Go(func() {
+					// A closing Observable which completes without emitting leaves the window open until the source completes.
+					for _, err := range closingSelector(o.v).SubscribeContext(closingCtx) {
+						if closingCtx.Err() != nil {
+							return
+						}
+						select {
+						case <-closingCtx.Done():
+						case closings <- closing{win, err}:
+						}
+						return
+					}
+				})
+			case c := <-closings:
+				if c.err != nil {
+					return c.err
+				}
+				cancels[c.window]()
+				delete(cancels, c.window)
+				w.close(c.window)
+			}
+		}
+	}
+}
+
+// WindowWhen branches out the source Observable values as a nested Observable, using closingSelector to close the window.
+// The first window is opened immediately, and a new one is opened as soon as the Observable returned by closingSelector emits.
+func WindowWhen[T, C any](closingSelector func() Observable[C]) OperatorFunc[T, Observable[T]] {
+	return func(input Observable[T]) Observable[Observable[T]] {
+		return windows(routeWhen(input, closingSelector))
+	}
+}
+
+// routeWhen keeps a single window open, replaced each time the closing Observable emits, see WindowWhen.
+func routeWhen[T, C any](input Observable[T], closingSelector func() Observable[C]) func(w *windowing[T]) error {
+	return func(w *windowing[T]) error {
+		ctx, cancel := context.WithCancel(w.ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		ch := subscribeAsync(ctx, &wg, input)
+
+		var (
+			closing       <-chan state[C]
+			cancelClosing context.CancelFunc
+		)
+		subscribe := func() {
+			closingCtx, c := context.WithCancel(ctx)
+			cancelClosing = c
+			closing = subscribeAsync(closingCtx, &wg, closingSelector())
+		}
+
+		current := w.open()
+		subscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case o := <-ch:
+				if o.err != nil {
+					return o.err
+				} else if !o.ok {
+					return nil
+				} else {
+					w.next(o.v)
+				}
+			case o := <-closing:
+				if o.err != nil {
+					return o.err
+				} else if !o.ok {
+					// The current window stays open until the source completes.
+					closing = nil
+					continue
+				}
+
+				cancelClosing()
+				w.close(current)
+				current = w.open()
+				subscribe()
+			}
+		}
+	}
+}